@@ -0,0 +1,50 @@
+package client
+
+// Accessibility describes the accessibility needs of the passenger. They are
+// passed to the API and filter the connections found, whose trains are
+// annotated with their accessibility information. Nothing is booked;
+// Connection.AssistanceRequired tells whether assistance has to be booked in
+// advance.
+type Accessibility struct {
+	// Wheelchair is set if the passenger uses a wheelchair. Only connections
+	// with wheelchair-accessible trains and step-free transfers are returned.
+	Wheelchair bool
+	// AssistanceDog is set if the passenger travels with an assistance dog.
+	AssistanceDog bool
+	// HandicappedPass is set if the passenger holds a disability pass.
+	HandicappedPass bool
+	// Attendant is set if the passenger travels with an attendant.
+	Attendant bool
+}
+
+func (a Accessibility) challengedFlags() challengedFlags {
+	return challengedFlags{
+		HasHandicappedPass: a.HandicappedPass,
+		HasAssistanceDog:   a.AssistanceDog,
+		HasWheelchair:      a.Wheelchair,
+		HasAttendant:       a.Attendant,
+	}
+}
+
+// SectionAccessibility is the accessibility information of a section.
+type SectionAccessibility struct {
+	// StepFree is set if the train can be boarded and left without steps,
+	// e.g. from a platform at floor level or using a lift.
+	StepFree bool `json:"stepFree"`
+	// WheelchairSpaces is the number of wheelchair spaces of the train.
+	WheelchairSpaces int `json:"wheelchairSpaces"`
+	// AssistanceRequired is set if boarding or leaving the train requires
+	// assistance, which has to be booked in advance.
+	AssistanceRequired bool `json:"assistanceRequired"`
+}
+
+// AssistanceRequired reports whether any train of the connection requires
+// assistance to be booked in advance.
+func (c Connection) AssistanceRequired() bool {
+	for _, s := range c.Sections {
+		if s.Kind().Vehicle() && s.Accessibility.AssistanceRequired {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package client_test
+
+import "testing"
+
+func TestAssistanceRequired(t *testing.T) {
+	conn := withTransfer("c0", 0, 5)
+	if conn.AssistanceRequired() {
+		t.Error("assistance required without any train requiring it")
+	}
+
+	conn.Sections[1].Accessibility.AssistanceRequired = true
+	if !conn.AssistanceRequired() {
+		t.Error("assistance not required with a train requiring it")
+	}
+}
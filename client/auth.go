@@ -1,13 +1,11 @@
 package client
 
 import (
-	"bytes"
-	"encoding/json"
 	"net/http"
 	"time"
 )
 
-const authURL = "https://tickets.oebb.at/api/domain/v3/init"
+const authPath = "/api/domain/v3/init"
 
 type authResponse struct {
 	AccessToken string `json:"accessToken"` // this is actually duplicated in the response
@@ -46,16 +44,20 @@ type AuthInfo struct {
 // a refresh token.
 // No credentials are actually required to interact with the API.
 func Auth() (AuthInfo, error) {
-	client := &http.Client{}
-
-	req, err := http.NewRequest("GET", authURL, nil)
-	resp, err := client.Do(req)
+	return DefaultClient.Auth()
+}
 
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(resp.Body)
+// Auth authenticates against the ÖBB API, see the package-level Auth.
+func (c *Client) Auth() (AuthInfo, error) {
+	req, err := http.NewRequest("GET", c.url(authPath), nil)
+	if err != nil {
+		return AuthInfo{}, err
+	}
 
 	var authResp authResponse
-	json.Unmarshal(buf.Bytes(), &authResp)
+	if err := c.do(req, &authResp); err != nil {
+		return AuthInfo{}, err
+	}
 
 	info := AuthInfo{
 		AccessToken: authResp.Token.AccessToken,
@@ -65,5 +67,7 @@ func Auth() (AuthInfo, error) {
 		ExpiresIn:   authResp.SessionTimeout,
 	}
 
-	return info, err
+	c.log("authenticated", "expiresIn", info.ExpiresIn)
+
+	return info, nil
 }
@@ -0,0 +1,333 @@
+package client
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default time-to-live values of cached responses.
+const (
+	// stations rarely change
+	DefaultStationCacheTTL = 24 * time.Hour
+
+	// timetables contain real-time information, so they get stale quickly
+	DefaultTimetableCacheTTL = time.Minute
+)
+
+// CacheStore stores cached API responses. Implementations must be safe for
+// concurrent use.
+type CacheStore interface {
+	// Get returns the value stored for key, if it has not expired yet.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value for key, expiring after ttl.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// Cache caches the responses of station lookups and timetable queries. It is
+// used by a Client if set as its Cache.
+//
+// Responses are keyed by a normalized form of the request, which does not
+// depend on the session it was sent with. Departure times are truncated to
+// the minute.
+type Cache struct {
+	Store CacheStore
+
+	// StationTTL is how long station lookups are cached. If zero,
+	// DefaultStationCacheTTL is used.
+	StationTTL time.Duration
+
+	// TimetableTTL is how long timetable queries are cached. If zero,
+	// DefaultTimetableCacheTTL is used.
+	TimetableTTL time.Duration
+
+	stations   cacheCounters
+	timetables cacheCounters
+}
+
+// NewCache returns a cache storing responses in store, using the default
+// TTLs.
+func NewCache(store CacheStore) *Cache {
+	return &Cache{Store: store}
+}
+
+// CacheCounts counts the hits and misses of a cache.
+type CacheCounts struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// HitRatio returns the fraction of lookups which were hits, or 0 if there were
+// no lookups.
+func (c CacheCounts) HitRatio() float64 {
+	total := c.Hits + c.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(total)
+}
+
+// CacheStats holds the hits and misses of a Cache, separately for station
+// lookups and timetable queries. Departure boards count as timetables.
+// Requests bypassing the cache are not counted.
+type CacheStats struct {
+	Stations   CacheCounts `json:"stations"`
+	Timetables CacheCounts `json:"timetables"`
+}
+
+type cacheCounters struct {
+	hits   uint64
+	misses uint64
+}
+
+func (c *cacheCounters) counts() CacheCounts {
+	return CacheCounts{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
+
+// Stats returns the number of hits and misses since the cache was created.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Stations:   c.stations.counts(),
+		Timetables: c.timetables.counts(),
+	}
+}
+
+type cacheKind int
+
+const (
+	stationsCache cacheKind = iota
+	timetablesCache
+)
+
+func (c *Cache) ttl(kind cacheKind) time.Duration {
+	switch kind {
+	case stationsCache:
+		if c.StationTTL != 0 {
+			return c.StationTTL
+		}
+		return DefaultStationCacheTTL
+	default:
+		if c.TimetableTTL != 0 {
+			return c.TimetableTTL
+		}
+		return DefaultTimetableCacheTTL
+	}
+}
+
+func (c *Cache) counters(kind cacheKind) *cacheCounters {
+	if kind == stationsCache {
+		return &c.stations
+	}
+	return &c.timetables
+}
+
+func (c *Cache) get(kind cacheKind, key string) ([]byte, bool) {
+	value, ok := c.Store.Get(key)
+	if ok {
+		atomic.AddUint64(&c.counters(kind).hits, 1)
+	} else {
+		atomic.AddUint64(&c.counters(kind).misses, 1)
+	}
+	return value, ok
+}
+
+func (c *Cache) set(kind cacheKind, key string, value []byte) {
+	c.Store.Set(key, value, c.ttl(kind))
+}
+
+// stationsCacheKey returns the cache key of a station lookup.
+func stationsCacheKey(name string) string {
+	return "stations:" + strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// timetableCacheKey returns the cache key of a timetable query.
+func timetableCacheKey(cr connectionRequest) (string, error) {
+	t, err := ParseTime(cr.DatetimeDeparture)
+	if err != nil {
+		return "", err
+	}
+	cr.DatetimeDeparture = t.Truncate(time.Minute).Format("2006-01-02T15:04")
+
+	key, err := json.Marshal(cr)
+	if err != nil {
+		return "", err
+	}
+	return "timetable:" + string(key), nil
+}
+
+// departuresCacheKey returns the cache key of a departure board query. Like
+// timetables, departure boards are cached using the timetable TTL.
+func departuresCacheKey(station Station, departureTime time.Time, maxResults int) string {
+	return fmt.Sprintf("departures:%d:%s:%d", station.Number, departureTime.Truncate(time.Minute).Format("2006-01-02T15:04"), maxResults)
+}
+
+// journeysCacheKey returns the cache key of a train lookup. Journeys contain
+// real-time information, so they are cached using the timetable TTL.
+func journeysCacheKey(category, number string, date time.Time) string {
+	return fmt.Sprintf("journeys:%s:%s:%s", category, number, date.Format("2006-01-02"))
+}
+
+// LRUStore is an in-memory CacheStore holding a limited number of entries.
+// When full, the least recently used entry is evicted.
+type LRUStore struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUStore returns an LRUStore holding up to size entries.
+func NewLRUStore(size int) *LRUStore {
+	return &LRUStore{
+		size:    size,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// Get implements CacheStore.
+func (s *LRUStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+		return nil, false
+	}
+
+	s.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set implements CacheStore.
+func (s *LRUStore) Set(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &lruEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of entries in the store, including expired ones
+// which were not evicted yet.
+func (s *LRUStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// DiskStore is a CacheStore keeping one file per entry in a directory, so
+// cached responses are shared between processes.
+type DiskStore struct {
+	Dir string
+}
+
+type diskEntry struct {
+	Expires time.Time `json:"expires"`
+	Value   []byte    `json:"value"`
+}
+
+// NewDiskStore returns a DiskStore keeping its entries in dir. The directory
+// is created when the first entry is stored.
+func NewDiskStore(dir string) *DiskStore {
+	return &DiskStore{Dir: dir}
+}
+
+func (s *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements CacheStore.
+func (s *DiskStore) Get(key string) ([]byte, bool) {
+	path := s.path(key)
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry diskEntry
+	if err := json.Unmarshal(bytes, &entry); err != nil {
+		os.Remove(path)
+		return nil, false
+	}
+
+	if time.Now().After(entry.Expires) {
+		os.Remove(path)
+		return nil, false
+	}
+
+	return entry.Value, true
+}
+
+// Set implements CacheStore. Errors are ignored, as the store only serves as
+// a cache.
+func (s *DiskStore) Set(key string, value []byte, ttl time.Duration) {
+	bytes, err := json.Marshal(diskEntry{Expires: time.Now().Add(ttl), Value: value})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(s.Dir, "tmp-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(bytes)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+
+	os.Rename(tmp.Name(), s.path(key))
+}
+
+// Clear removes all entries from the store.
+func (s *DiskStore) Clear() error {
+	return os.RemoveAll(s.Dir)
+}
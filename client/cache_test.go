@@ -0,0 +1,109 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+func TestCache(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddStations("wien hbf", wien)
+	srv.AddConnections(hourly(3)...)
+	c.Cache = client.NewCache(client.NewLRUStore(10))
+
+	for i, name := range []string{"Wien Hbf", "wien hbf"} {
+		if _, err := c.GetStations(name, auth); err != nil {
+			t.Fatalf("GetStations() failed: %v", err)
+		}
+		// departures within the same minute share a cache entry
+		depTime := start.Add(time.Duration(i) * time.Second)
+		connections, err := c.GetConnections(wien, graz, auth, depTime, 3)
+		if err != nil {
+			t.Fatalf("GetConnections() failed: %v", err)
+		}
+		assertIDs(t, connections, "c0", "c1", "c2")
+	}
+
+	if n := len(srv.Requests(oebbtest.EndpointStations)); n != 1 {
+		t.Errorf("got %d station requests, want 1", n)
+	}
+	if n := len(srv.Requests(oebbtest.EndpointTimetable)); n != 1 {
+		t.Errorf("got %d timetable requests, want 1", n)
+	}
+
+	want := client.CacheStats{
+		Stations:   client.CacheCounts{Hits: 1, Misses: 1},
+		Timetables: client.CacheCounts{Hits: 1, Misses: 1},
+	}
+	if got := c.Cache.Stats(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+
+	c.BypassCache = true
+	if _, err := c.GetStations("Wien Hbf", auth); err != nil {
+		t.Fatalf("GetStations() failed: %v", err)
+	}
+	if n := len(srv.Requests(oebbtest.EndpointStations)); n != 2 {
+		t.Errorf("got %d station requests after bypassing the cache, want 2", n)
+	}
+}
+
+func TestCacheFailuresNotStored(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddStations("wien", wien)
+	c.Cache = client.NewCache(client.NewLRUStore(10))
+
+	srv.Fail(oebbtest.EndpointStations, oebbtest.ServerError)
+	if _, err := c.GetStations("Wien", auth); err == nil {
+		t.Fatal("GetStations() succeeded, want error")
+	}
+
+	stations, err := c.GetStations("Wien", auth)
+	if err != nil {
+		t.Fatalf("GetStations() failed: %v", err)
+	}
+	if len(stations) != 1 {
+		t.Errorf("got %d stations, want 1", len(stations))
+	}
+}
+
+func TestLRUStore(t *testing.T) {
+	s := client.NewLRUStore(2)
+	s.Set("a", []byte("1"), time.Hour)
+	s.Set("b", []byte("2"), time.Hour)
+	s.Get("a")
+	s.Set("c", []byte("3"), time.Hour)
+
+	if _, ok := s.Get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("entry %q missing", key)
+		}
+	}
+
+	s.Set("d", []byte("4"), -time.Second)
+	if _, ok := s.Get("d"); ok {
+		t.Error("expired entry returned")
+	}
+}
+
+func TestDiskStore(t *testing.T) {
+	s := client.NewDiskStore(t.TempDir())
+	s.Set("a", []byte("1"), time.Hour)
+	s.Set("b", []byte("2"), -time.Second)
+
+	if value, ok := s.Get("a"); !ok || string(value) != "1" {
+		t.Errorf("got %q, %v, want \"1\", true", value, ok)
+	}
+	if _, ok := s.Get("b"); ok {
+		t.Error("expired entry returned")
+	}
+	if _, ok := s.Get("c"); ok {
+		t.Error("missing entry returned")
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+// CancellationStatus tells whether a train or connection is cancelled.
+type CancellationStatus string
+
+// The cancellation statuses.
+const (
+	NotCancelled       CancellationStatus = ""
+	PartiallyCancelled CancellationStatus = "partial"
+	Cancelled          CancellationStatus = "cancelled"
+)
+
+// CancellationStatus returns whether the train of the section is cancelled.
+func (s Section) CancellationStatus() CancellationStatus {
+	switch {
+	case s.Cancelled:
+		return Cancelled
+	case s.PartiallyCancelled:
+		return PartiallyCancelled
+	default:
+		return NotCancelled
+	}
+}
+
+// Cancelled reports whether any section of the connection is cancelled. Use
+// CancellationStatus to also detect trains which only skip some stops.
+func (c Connection) Cancelled() bool {
+	for _, s := range c.Sections {
+		if s.Cancelled {
+			return true
+		}
+	}
+	return false
+}
+
+// CancellationStatus returns Cancelled if all trains of the connection are
+// cancelled, PartiallyCancelled if some are and NotCancelled otherwise.
+func (c Connection) CancellationStatus() CancellationStatus {
+	trains, cancelled, partial := 0, 0, 0
+	for _, s := range c.Sections {
+		if !s.Kind().Vehicle() {
+			continue
+		}
+		trains++
+		switch s.CancellationStatus() {
+		case Cancelled:
+			cancelled++
+		case PartiallyCancelled:
+			partial++
+		}
+	}
+
+	switch {
+	case cancelled > 0 && cancelled == trains:
+		return Cancelled
+	case cancelled > 0 || partial > 0:
+		return PartiallyCancelled
+	default:
+		return NotCancelled
+	}
+}
@@ -0,0 +1,32 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/chrboe/oebb/client"
+)
+
+func TestCancellationStatus(t *testing.T) {
+	conn := withTransfer("c0", 0, 5)
+	if got := conn.CancellationStatus(); got != client.NotCancelled {
+		t.Errorf("got status %q, want not cancelled", got)
+	}
+
+	conn.Sections[1].PartiallyCancelled = true
+	if got := conn.CancellationStatus(); got != client.PartiallyCancelled {
+		t.Errorf("got status %q, want partially cancelled", got)
+	}
+	if conn.Cancelled() {
+		t.Error("Cancelled() is true for a train which only skips some stops")
+	}
+
+	conn.Sections[0].Cancelled = true
+	if got := conn.CancellationStatus(); got != client.PartiallyCancelled || !conn.Cancelled() {
+		t.Errorf("got status %q with one train cancelled, want partially cancelled", got)
+	}
+
+	conn.Sections[1].Cancelled = true
+	if got := conn.CancellationStatus(); got != client.Cancelled {
+		t.Errorf("got status %q with all trains cancelled, want cancelled", got)
+	}
+}
@@ -0,0 +1,296 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// redacted replaces sensitive values in recorded interactions.
+const redacted = "REDACTED"
+
+// redactedHeaders are the request headers which identify a session.
+var redactedHeaders = []string{"AccessToken", "SessionId", "x-ts-supportid"}
+
+// redactedFields are the JSON fields in response bodies which identify a
+// session.
+var redactedFields = map[string]bool{
+	"accessToken":  true,
+	"refreshToken": true,
+	"sessionId":    true,
+	"supportId":    true,
+	"cashId":       true,
+	"xffxIP":       true,
+}
+
+// RecordedRequest is a request as stored in a cassette.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response as stored in a cassette.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request along with the response to it.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is a list of interactions with the API, which can be replayed
+// later using a Replayer.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette from path.
+func LoadCassette(path string) (*Cassette, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(bytes, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	bytes, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, bytes, 0600)
+}
+
+// Recorder is an http.RoundTripper which records all requests and responses
+// passing through it. Session tokens are redacted from the recording, so
+// cassettes can be shared safely.
+type Recorder struct {
+	// Transport is used to send the requests. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Path is the file the cassette is saved to after every interaction,
+	// so the recording survives crashes. If empty, the cassette is only kept
+	// in memory.
+	Path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder which saves its cassette to path.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{
+		Path: path,
+	}
+}
+
+// Cassette returns a copy of the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := &Cassette{}
+	c.Interactions = append(c.Interactions, r.cassette.Interactions...)
+	return c
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// a RoundTripper must not modify the original request
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	reqHeader := req.Header.Clone()
+	for _, h := range redactedHeaders {
+		if reqHeader.Get(h) != "" {
+			reqHeader.Set(h, redacted)
+		}
+	}
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: reqHeader,
+			Body:   string(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(redactBody(respBody)),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if r.Path != "" {
+		if err := r.cassette.Save(r.Path); err != nil {
+			return nil, fmt.Errorf("failed to save cassette: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// redactBody replaces the values of session related fields in a JSON body.
+// Bodies which are not JSON are returned unchanged.
+func redactBody(body []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+
+	if !redactValue(v) {
+		return body
+	}
+
+	redactedBody, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redactedBody
+}
+
+// redactValue redacts v in place and reports whether anything was redacted.
+func redactValue(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if _, ok := value.(string); ok && redactedFields[key] {
+				v[key] = redacted
+				changed = true
+			} else if redactValue(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactValue(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// Replayer is an http.RoundTripper which answers requests from a cassette
+// instead of sending them. Every recorded interaction is replayed at most once.
+//
+// A request is matched to the first unused interaction with the same method,
+// URL and body. If there is none, the first unused interaction with the same
+// method and URL is used, so requests containing the current time can still be
+// replayed.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer returns a Replayer for the interactions in c.
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.String() {
+			continue
+		}
+		if interaction.Request.Body == string(body) {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+
+	if match < 0 {
+		return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, req.URL)
+	}
+	r.used[match] = true
+
+	recorded := r.cassette.Interactions[match].Response
+	header := recorded.Header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package client_test
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	srv := oebbtest.NewServer()
+	defer srv.Close()
+	srv.AddStations("wien", wien)
+	srv.AddConnections(hourly(8)...)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := client.NewRecorder(path)
+	recorder.Transport = srv.Client().HTTPClient.Transport
+	recording := &client.Client{BaseURL: srv.URL, HTTPClient: &http.Client{Transport: recorder}}
+
+	auth, err := recording.Auth()
+	if err != nil {
+		t.Fatalf("Auth() failed: %v", err)
+	}
+	if _, err := recording.GetStations("Wien", auth); err != nil {
+		t.Fatalf("GetStations() failed: %v", err)
+	}
+	recorded, err := recording.GetConnections(wien, graz, auth, start, 8)
+	if err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	cassette, err := client.LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette() failed: %v", err)
+	}
+
+	for _, interaction := range cassette.Interactions {
+		for _, h := range []string{"AccessToken", "SessionId", "x-ts-supportid"} {
+			if v := interaction.Request.Header.Get(h); v != "" && v != "REDACTED" {
+				t.Errorf("header %s of %s was not redacted: %q", h, interaction.Request.URL, v)
+			}
+		}
+		if strings.Contains(interaction.Response.Body, auth.AccessToken) {
+			t.Errorf("response of %s contains the access token", interaction.Request.URL)
+		}
+	}
+
+	srv.Close()
+
+	replaying := &client.Client{
+		BaseURL:    srv.URL,
+		HTTPClient: &http.Client{Transport: client.NewReplayer(cassette)},
+	}
+
+	auth, err = replaying.Auth()
+	if err != nil {
+		t.Fatalf("replayed Auth() failed: %v", err)
+	}
+	stations, err := replaying.GetStations("Wien", auth)
+	if err != nil {
+		t.Fatalf("replayed GetStations() failed: %v", err)
+	}
+	if len(stations) != 1 || stations[0].Number != wien.Number {
+		t.Errorf("replayed stations %+v, want %+v", stations, wien)
+	}
+	replayed, err := replaying.GetConnections(wien, graz, auth, start, 8)
+	if err != nil {
+		t.Fatalf("replayed GetConnections() failed: %v", err)
+	}
+	assertIDs(t, replayed, ids(recorded)...)
+
+	if _, err := replaying.GetStations("Wien", auth); err == nil {
+		t.Error("interactions should only be replayed once")
+	}
+}
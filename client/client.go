@@ -0,0 +1,154 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the base URL of the ÖBB Tickets API.
+const DefaultBaseURL = "https://tickets.oebb.at"
+
+// Client is a client for the ÖBB Tickets API. The zero value is ready to use
+// and talks to the real API.
+type Client struct {
+	// BaseURL is the URL the API paths are appended to. If empty,
+	// DefaultBaseURL is used.
+	BaseURL string
+
+	// HTTPClient is used to send requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+
+	// Logger receives diagnostic events, if set.
+	Logger Logger
+
+	// LogBodies additionally passes the request and response bodies to
+	// Logger, with session tokens redacted.
+	LogBodies bool
+
+	// Concurrency is the maximum number of requests sent at the same time
+	// when fetching more connections than fit into a single response. A
+	// value of 1 or less fetches them one after another.
+	Concurrency int
+
+	// Cache caches station lookups and timetable queries, if set.
+	Cache *Cache
+
+	// BypassCache makes requests skip the lookup in Cache. Their responses
+	// are still stored, so this refreshes the cache.
+	BypassCache bool
+}
+
+// DefaultClient is the client used by the package-level functions.
+var DefaultClient = &Client{}
+
+func (c *Client) url(path string) string {
+	if c.BaseURL == "" {
+		return DefaultBaseURL + path
+	}
+	return strings.TrimSuffix(c.BaseURL, "/") + path
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// newRequest creates a request for the API path, authenticated using a.
+func (c *Client) newRequest(method, path string, body io.Reader, a AuthInfo) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.url(path), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Channel", a.Channel)
+	req.Header.Add("AccessToken", a.AccessToken)
+	req.Header.Add("SessionId", a.SessionID)
+
+	return req, nil
+}
+
+// do sends req and decodes the JSON response into v.
+func (c *Client) do(req *http.Request, v interface{}) error {
+	body, err := c.send(req)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// doCached is like do, but answers the request from the cache of the client
+// if it holds a response for key. Successful responses are stored in the
+// cache.
+func (c *Client) doCached(req *http.Request, kind cacheKind, key string, v interface{}) error {
+	if c.Cache == nil {
+		return c.do(req, v)
+	}
+
+	if !c.BypassCache {
+		if body, ok := c.Cache.get(kind, key); ok {
+			if err := json.Unmarshal(body, v); err == nil {
+				c.log("cache hit", "method", req.Method, "url", req.URL.String())
+				return nil
+			}
+		}
+		c.log("cache miss", "method", req.Method, "url", req.URL.String())
+	}
+
+	body, err := c.send(req)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return err
+	}
+
+	c.Cache.set(kind, key, body)
+	return nil
+}
+
+// send sends req and returns the body of a successful response.
+func (c *Client) send(req *http.Request) ([]byte, error) {
+	if c.LogBodies && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ := ioutil.ReadAll(body)
+			c.log("request body", "method", req.Method, "url", req.URL.String(), "body", string(reqBody))
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		c.log("request failed", "method", req.Method, "url", req.URL.String(), "latency", time.Since(start), "error", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	c.log("request", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "latency", time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.LogBodies {
+		c.log("response body", "method", req.Method, "url", req.URL.String(), "body", string(redactBody(body)))
+	}
+
+	switch {
+	case resp.StatusCode == 440:
+		// login time-out: session expird
+		return nil, &SessionTimeoutError{}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return body, nil
+}
@@ -4,13 +4,21 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	connectionsURL = "https://tickets.oebb.at/api/hafas/v4/timetable"
-	fetchMax       = 6 // the API supports returning a maximum of 6 results
+	connectionsPath = "/api/hafas/v4/timetable"
+	fetchMax        = 6 // the API supports returning a maximum of 6 results
+
+	// minWindow is the shortest time window fetched in parallel
+	minWindow = 10 * time.Minute
+
+	// maxSkipped is the number of consecutive connections not matching the
+	// client-side options after which a search gives up
+	maxSkipped = 60
 )
 
 //
@@ -136,6 +144,29 @@ type Section struct {
 	Category    Category         `json:"category,omitempty"`
 	Type        string           `json:"type"`
 	HasRealtime bool             `json:"hasRealtime"`
+
+	// The following fields have not been checked against a recorded
+	// response. Their JSON names, and those of Note, ClassOccupancy and
+	// SectionAccessibility, are guesses; if the API names them differently,
+	// they are left empty. Record a search with
+	// "oebb-cli --record cassette.json search Wien Graz" to verify them.
+
+	// Distance is the length of a walk in meters, see Kind.
+	Distance int `json:"distance"`
+
+	// Cancelled is set if the train does not run between From and To, and
+	// PartiallyCancelled if it only skips some of the stops in between or
+	// ends early. Use CancellationStatus to tell them apart.
+	Cancelled          bool   `json:"cancelled"`
+	PartiallyCancelled bool   `json:"partiallyCancelled"`
+	CancellationReason string `json:"cancellationReason"`
+	Notes              []Note `json:"notes"`
+
+	// Occupancy is the expected load of the train.
+	Occupancy ClassOccupancy `json:"occupancy"`
+
+	// Accessibility is the accessibility information of the train.
+	Accessibility SectionAccessibility `json:"accessibility"`
 }
 
 type Connection struct {
@@ -145,34 +176,150 @@ type Connection struct {
 	Sections []Section        `json:"sections"`
 	Switches int              `json:"switches"`
 	Duration int              `json:"duration"`
+	// Notes are the notes about the connection as a whole, the notes about
+	// single trains are in Sections. Like the newer fields of Section, its
+	// JSON name has not been verified.
+	Notes []Note `json:"notes"`
+}
+
+// ConnectionOptions holds optional parameters for connection searches. The
+// zero value corresponds to the defaults of the ÖBB web app.
+type ConnectionOptions struct {
+	// Direct restricts the results to connections without any changes.
+	Direct bool
+
+	// MinTransfer is the minimum scheduled time for every transfer. If set,
+	// the API is asked for connections with longer transfer times, and
+	// connections with shorter transfers are skipped.
+	MinTransfer time.Duration
+
+	// MaxTransfers is the maximum number of transfers, if greater than zero.
+	// Use Direct for connections without transfers.
+	MaxTransfers int
+
+	// MaxDuration is the maximum total travel time, if greater than zero.
+	MaxDuration time.Duration
+
+	// Only restricts the results to connections whose trains all belong to
+	// one of these categories, e.g. "RJ" or "ICE". Exclude skips
+	// connections with a train of one of these categories, e.g. "Bus".
+	// Categories are compared case-insensitively with the short name,
+	// display name and name of a train's category, after fetching the
+	// connections. Trains cannot be filtered by operator, as connections do
+	// not include it.
+	Only, Exclude []string
+
+	// MaxOccupancy skips connections with a train expected to be more
+	// crowded, if set. Trains without occupancy information are never
+	// skipped. FirstClass selects the class whose occupancy is used.
+	MaxOccupancy Occupancy
+	FirstClass   bool
+
+	// IncludeCancelled asks the API to include connections which are
+	// cancelled, instead of leaving them out.
+	IncludeCancelled bool
+
+	// Accessibility are the accessibility needs of the passenger.
+	Accessibility Accessibility
+
+	// SortType is the order the API returns connections in, SortDeparture
+	// or SortArrival. If empty, SortDeparture is used. Results spanning
+	// several pages are only sorted within each page, use SortConnections to
+	// sort all of them.
+	SortType string
+}
+
+// filtered reports whether some options are applied to the results instead of
+// being sent to the API.
+func (o ConnectionOptions) filtered() bool {
+	return o.MinTransfer > 0 || o.MaxTransfers > 0 || o.MaxDuration > 0 || len(o.Only) > 0 || len(o.Exclude) > 0 ||
+		o.MaxOccupancy != OccupancyUnknown
+}
+
+// matchesCategory reports whether c is one of the categories in names.
+func matchesCategory(c Category, names []string) bool {
+	for _, name := range names {
+		if strings.EqualFold(name, c.ShortName) || strings.EqualFold(name, c.DisplayName) || strings.EqualFold(name, c.Name) {
+			return true
+		}
+	}
+	return false
 }
 
-func fetchConnections(client *http.Client, from, to Station, a AuthInfo, departureTime time.Time, numResults int) ([]Connection, error) {
+// matchCategories reports whether the trains of conn satisfy Only and
+// Exclude.
+func (o ConnectionOptions) matchCategories(conn Connection) bool {
+	for _, s := range conn.Sections {
+		if !s.Kind().Vehicle() {
+			continue
+		}
+		if len(o.Only) > 0 && !matchesCategory(s.Category, o.Only) {
+			return false
+		}
+		if matchesCategory(s.Category, o.Exclude) {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether conn satisfies the options which the API does not
+// support.
+func (o ConnectionOptions) match(conn Connection) bool {
+	if !o.matchCategories(conn) {
+		return false
+	}
+	if o.MaxOccupancy != OccupancyUnknown && conn.Occupancy(o.FirstClass) > o.MaxOccupancy {
+		return false
+	}
+	if o.MaxDuration > 0 && time.Duration(conn.Duration)*time.Millisecond > o.MaxDuration {
+		return false
+	}
+	if o.MinTransfer <= 0 && o.MaxTransfers <= 0 {
+		return true
+	}
+
+	transfers, err := conn.Transfers()
+	if err != nil {
+		return false
+	}
+	if o.MaxTransfers > 0 && len(transfers) > o.MaxTransfers {
+		return false
+	}
+	for _, t := range transfers {
+		if t.Scheduled < o.MinTransfer {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Client) fetchConnections(from, to Station, a AuthInfo, departureTime time.Time, numResults int, opts ConnectionOptions) ([]Connection, error) {
+	sortType := opts.SortType
+	if sortType == "" {
+		sortType = SortDeparture
+	}
+
 	cr := connectionRequest{
 		Reverse:           false,
-		DatetimeDeparture: departureTime.Format("2006-01-02T15:04:05.999"),
+		DatetimeDeparture: FormatTime(departureTime),
 		Filter: connectionsFilter{
 			Regionaltrains:     false,
-			Direct:             false,
-			ChangeTime:         false,
-			Wheelchair:         false,
+			Direct:             opts.Direct,
+			ChangeTime:         opts.MinTransfer > 0,
+			Wheelchair:         opts.Accessibility.Wheelchair,
 			Bikes:              false,
 			Trains:             false,
 			Motorail:           false,
-			DroppedConnections: false,
+			DroppedConnections: opts.IncludeCancelled,
 		},
 		Passengers: []passenger{
 			passenger{
-				Type:       "ADULT",
-				ID:         1554277150,
-				Me:         false,
-				Remembered: false,
-				ChallengedFlags: challengedFlags{
-					HasHandicappedPass: false,
-					HasAssistanceDog:   false,
-					HasWheelchair:      false,
-					HasAttendant:       false,
-				},
+				Type:                "ADULT",
+				ID:                  1554277150,
+				Me:                  false,
+				Remembered:          false,
+				ChallengedFlags:     opts.Accessibility.challengedFlags(),
 				Relations:           []interface{}{},
 				Cards:               []interface{}{},
 				BirthdateChangeable: true,
@@ -192,93 +339,286 @@ func fetchConnections(client *http.Client, from, to Station, a AuthInfo, departu
 			NoVbxFilter:         false,
 			NoCategoriesFilter:  false,
 		},
-		SortType: "DEPARTURE",
+		SortType: sortType,
 		From:     from,
 		To:       to,
 	}
 
 	body, err := json.Marshal(cr)
+	if err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequest("POST", connectionsURL, bytes.NewBuffer(body))
+	req, err := c.newRequest("POST", connectionsPath, bytes.NewBuffer(body), a)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Channel", a.Channel)
-	req.Header.Add("AccessToken", a.AccessToken)
-	req.Header.Add("SessionId", a.SessionID)
 	req.Header.Add("x-ts-supportid", "WEB_"+a.SupportID)
-	resp, err := client.Do(req)
 
+	key, err := timetableCacheKey(cr)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 
-	switch resp.StatusCode {
-	case 440:
-		// login time-out: session expird
-		return nil, &SessionTimeoutError{}
-	}
-
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(resp.Body)
-
 	connections := &connectionsResponse{}
-	json.Unmarshal(buf.Bytes(), connections)
+	if err := c.doCached(req, timetablesCache, key, connections); err != nil {
+		return nil, err
+	}
 
 	return connections.Connections, nil
 }
 
+// GetConnections returns up to numResults connections from one station to
+// another, departing at or after departureTime.
 func GetConnections(from, to Station, a AuthInfo, departureTime time.Time, numResults int) ([]Connection, error) {
-	client := &http.Client{}
+	return DefaultClient.GetConnections(from, to, a, departureTime, numResults)
+}
+
+// GetConnectionsWithOptions is like GetConnections, but allows the search to
+// be customized using opts.
+func GetConnectionsWithOptions(from, to Station, a AuthInfo, departureTime time.Time, numResults int, opts ConnectionOptions) ([]Connection, error) {
+	return DefaultClient.GetConnectionsWithOptions(from, to, a, departureTime, numResults, opts)
+}
 
-	var connections []Connection
-	remaining := numResults
+// GetConnections returns connections between two stations, see the
+// package-level GetConnections.
+func (c *Client) GetConnections(from, to Station, a AuthInfo, departureTime time.Time, numResults int) ([]Connection, error) {
+	return c.GetConnectionsWithOptions(from, to, a, departureTime, numResults, ConnectionOptions{})
+}
+
+// GetConnectionsWithOptions returns connections between two stations, see the
+// package-level GetConnectionsWithOptions.
+func (c *Client) GetConnectionsWithOptions(from, to Station, a AuthInfo, departureTime time.Time, numResults int, opts ConnectionOptions) ([]Connection, error) {
+	if c.Concurrency > 1 && numResults > fetchMax {
+		return c.fetchParallel(from, to, a, departureTime, numResults, opts)
+	}
 
-	startTime := departureTime
+	return c.fetchRange(nil, from, to, a, departureTime, time.Time{}, numResults, opts)
+}
+
+// fetchRange fetches up to max connections departing at or after start, page
+// by page, and appends them to connections. Connections which are already in
+// connections are skipped, as are connections which do not match the options
+// applied client-side. If end is not zero, it stops as soon as a page reaches
+// end.
+func (c *Client) fetchRange(connections []Connection, from, to Station, a AuthInfo, start, end time.Time, max int, opts ConnectionOptions) ([]Connection, error) {
+	remaining := max
+
+	// departures are parsed as wall-clock times in UTC, so compare them with
+	// the wall-clock time of start
+	startTime := wallClock(start)
+
+	seen := make(map[string]bool, len(connections))
+	for _, conn := range connections {
+		seen[conn.ID] = true
+	}
+
+	// number of connections skipped since the last match
+	skipped := 0
 
 	// fetch results, up to "fetchMax" at a time
 	for {
+		if !end.IsZero() && !startTime.Before(end) {
+			break
+		}
+
 		// try to fetch all remaining
 		toFetch := remaining
-		if remaining > fetchMax {
+		if remaining > fetchMax || opts.filtered() {
 			// ... but cap at fetchMax
 			toFetch = fetchMax
 		}
-		newConnections, err := fetchConnections(client, from, to, a, startTime, toFetch)
+		newConnections, err := c.fetchConnections(from, to, a, startTime, toFetch, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch connections: %w", err)
 		}
 
+		if len(newConnections) == 0 {
+			// there are no more connections after startTime
+			break
+		}
+
+		fetched := len(newConnections)
+
 		// remove all connections that we already have
-		for _, conn := range connections {
-			for i := len(newConnections) - 1; i >= 0; i-- {
-				if newConnections[i].ID == conn.ID {
-					copy(newConnections[i:], newConnections[i+1:])
-					newConnections[len(newConnections)-1] = Connection{}
-					newConnections = newConnections[:len(newConnections)-1]
-				}
+		n := 0
+		for _, conn := range newConnections {
+			if !seen[conn.ID] {
+				seen[conn.ID] = true
+				newConnections[n] = conn
+				n++
 			}
 		}
-
-		remaining -= len(newConnections)
+		newConnections = newConnections[:n]
 
 		if len(newConnections) == 0 {
 			// oops, we removed all connections. add 1 minute to the start time.
 			startTime = startTime.Add(1 * time.Minute)
+			c.log("only duplicate connections, retrying", "start", startTime)
 			continue
-		} else {
-			// startTime for next request is departure of last connection
-			startTime, err = time.Parse("2006-01-02T15:04:05.999", newConnections[len(newConnections)-1].From.Departure)
+		}
+
+		// startTime for next request is the latest departure, which is the
+		// departure of the last connection unless sorted differently
+		for _, conn := range newConnections {
+			dep, err := ParseTime(conn.From.Departure)
 			if err != nil {
 				return nil, fmt.Errorf("invalid time returned by api: %w", err)
 			}
+			if dep.After(startTime) {
+				startTime = dep
+			}
 		}
 
-		connections = append(connections, newConnections...)
+		matching := newConnections[:0]
+		for _, conn := range newConnections {
+			if opts.match(conn) {
+				matching = append(matching, conn)
+			}
+		}
+		if len(matching) > 0 {
+			skipped = 0
+		}
+		skipped += len(newConnections) - len(matching)
+
+		if len(matching) > remaining {
+			matching = matching[:remaining]
+		}
+		remaining -= len(matching)
+
+		c.log("fetched connections", "start", startTime, "fetched", fetched, "new", len(matching), "remaining", remaining)
+
+		connections = append(connections, matching...)
 
 		if remaining <= 0 {
 			break
 		}
+
+		if skipped >= maxSkipped {
+			c.log("too many connections not matching the options, giving up", "skipped", skipped)
+			break
+		}
 	}
 
 	return connections, nil
 }
+
+// fetchParallel fetches connections using up to c.Concurrency requests at the
+// same time. After fetching the first page, the time between its first and
+// last departure is used to split the remaining search into time windows of
+// roughly one page each. These windows are fetched concurrently, then merged
+// and deduplicated.
+func (c *Client) fetchParallel(from, to Station, a AuthInfo, departureTime time.Time, numResults int, opts ConnectionOptions) ([]Connection, error) {
+	connections, err := c.fetchRange(nil, from, to, a, departureTime, time.Time{}, fetchMax, opts)
+	if err != nil || len(connections) < fetchMax {
+		return connections, err
+	}
+
+	first, last, err := departureRange(connections)
+	if err != nil {
+		return nil, err
+	}
+
+	window := last.Sub(first)
+	if window < minWindow {
+		window = minWindow
+	}
+
+	remaining := numResults - len(connections)
+	windows := (remaining + fetchMax - 1) / fetchMax
+
+	c.log("fetching connections in parallel", "windows", windows, "window", window, "concurrency", c.Concurrency)
+
+	results := make([][]Connection, windows)
+	errs := make([]error, windows)
+	sem := make(chan struct{}, c.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < windows; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := last.Add(time.Duration(i) * window)
+			results[i], errs[i] = c.fetchRange(nil, from, to, a, start, start.Add(window), remaining, opts)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		connections = mergeConnections(connections, results[i])
+	}
+
+	// the windows are in order of departure, so unless the API sorts
+	// differently, only connections of neighbouring windows may be out of
+	// order
+	if opts.SortType == "" || opts.SortType == SortDeparture {
+		SortConnections(connections, ByDeparture)
+	}
+
+	// the windows may have been too short, continue after the latest
+	// connection found so far
+	if missing := numResults - len(connections); missing > 0 {
+		_, latest, err := departureRange(connections)
+		if err != nil {
+			return nil, err
+		}
+
+		connections, err = c.fetchRange(connections, from, to, a, latest, time.Time{}, missing, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(connections) > numResults {
+		connections = connections[:numResults]
+	}
+
+	return connections, nil
+}
+
+// departureRange returns the earliest and latest departure of connections,
+// which need not be sorted by departure.
+func departureRange(connections []Connection) (first, last time.Time, err error) {
+	for i, conn := range connections {
+		dep, err := ParseTime(conn.From.Departure)
+		if err != nil {
+			return first, last, fmt.Errorf("invalid time returned by api: %w", err)
+		}
+		if i == 0 || dep.Before(first) {
+			first = dep
+		}
+		if dep.After(last) {
+			last = dep
+		}
+	}
+	return first, last, nil
+}
+
+// mergeConnections appends the connections of b which are not in a yet.
+func mergeConnections(a, b []Connection) []Connection {
+	seen := make(map[string]bool, len(a))
+	for _, conn := range a {
+		seen[conn.ID] = true
+	}
+
+	for _, conn := range b {
+		if !seen[conn.ID] {
+			seen[conn.ID] = true
+			a = append(a, conn)
+		}
+	}
+
+	return a
+}
+
+// wallClock returns the wall-clock time of t in UTC, like the times parsed
+// from API responses, which carry no time zone.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
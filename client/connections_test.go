@@ -0,0 +1,547 @@
+package client_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+var (
+	wien = client.Station{Name: "Wien Hbf", Number: 1290401}
+	graz = client.Station{Name: "Graz Hbf", Number: 8100173}
+
+	start = oebbtest.Start
+)
+
+// connection returns a connection with the given ID departing minutes after
+// start.
+func connection(id string, minutes int) client.Connection {
+	dep := start.Add(time.Duration(minutes) * time.Minute)
+	arr := dep.Add(2*time.Hour + 35*time.Minute)
+	return client.Connection{
+		ID:       id,
+		From:     client.DepartureStation{Name: wien.Name, Departure: client.FormatTime(dep)},
+		To:       client.ArrivalStation{Name: graz.Name, Arrival: client.FormatTime(arr)},
+		Duration: int((2*time.Hour + 35*time.Minute) / time.Millisecond),
+	}
+}
+
+// hourly returns n connections departing every hour, starting at start.
+func hourly(n int) []client.Connection {
+	var connections []client.Connection
+	for i := 0; i < n; i++ {
+		connections = append(connections, connection(fmt.Sprintf("c%d", i), i*60))
+	}
+	return connections
+}
+
+func newServer(t *testing.T) (*oebbtest.Server, *client.Client, client.AuthInfo) {
+	t.Helper()
+
+	srv := oebbtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	c := srv.Client()
+	auth, err := c.Auth()
+	if err != nil {
+		t.Fatalf("Auth() failed: %v", err)
+	}
+
+	return srv, c, auth
+}
+
+func ids(connections []client.Connection) []string {
+	var ids []string
+	for _, c := range connections {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, connections []client.Connection, want ...string) {
+	t.Helper()
+
+	got := ids(connections)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got connections %v, want %v", got, want)
+	}
+}
+
+func TestGetConnectionsSinglePage(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddConnections(hourly(10)...)
+
+	connections, err := c.GetConnections(wien, graz, auth, start, 3)
+	if err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	assertIDs(t, connections, "c0", "c1", "c2")
+
+	trs, err := srv.TimetableRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trs) != 1 {
+		t.Fatalf("got %d timetable requests, want 1", len(trs))
+	}
+	if trs[0].Count != 3 {
+		t.Errorf("requested %d connections, want 3", trs[0].Count)
+	}
+	if trs[0].From.Number != wien.Number || trs[0].To.Number != graz.Number {
+		t.Errorf("requested %d -> %d, want %d -> %d", trs[0].From.Number, trs[0].To.Number, wien.Number, graz.Number)
+	}
+	if trs[0].DatetimeDeparture != "2020-05-04T08:00:00" {
+		t.Errorf("requested departure %s, want 2020-05-04T08:00:00", trs[0].DatetimeDeparture)
+	}
+}
+
+func TestGetConnectionsHeaders(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddConnections(hourly(1)...)
+
+	if _, err := c.GetConnections(wien, graz, auth, start, 1); err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	req := srv.Requests(oebbtest.EndpointTimetable)[0]
+	want := map[string]string{
+		"AccessToken":    auth.AccessToken,
+		"SessionId":      auth.SessionID,
+		"Channel":        auth.Channel,
+		"x-ts-supportid": "WEB_" + auth.SupportID,
+		"Content-Type":   "application/json",
+	}
+	for header, value := range want {
+		if got := req.Header.Get(header); got != value {
+			t.Errorf("header %s is %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestGetConnectionsPagination(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddConnections(hourly(20)...)
+
+	connections, err := c.GetConnections(wien, graz, auth, start, 14)
+	if err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	assertIDs(t, connections, "c0", "c1", "c2", "c3", "c4", "c5", "c6",
+		"c7", "c8", "c9", "c10", "c11", "c12", "c13")
+
+	trs, err := srv.TimetableRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tr := range trs {
+		if tr.Count > 6 {
+			t.Errorf("request %d asked for %d connections, the API supports at most 6", i, tr.Count)
+		}
+	}
+
+	// every following page starts at the departure of the last connection
+	// of the previous page
+	if len(trs) < 2 || trs[1].DatetimeDeparture != "2020-05-04T13:00:00" {
+		t.Errorf("second page should start at 13:00, got requests %+v", trs)
+	}
+}
+
+func TestGetConnectionsPaginationTimeZone(t *testing.T) {
+	// the same wall-clock time as start, in UTC and west of UTC
+	honolulu := time.FixedZone("HST", -10*60*60)
+	departures := []time.Time{start, time.Date(2020, 5, 4, 8, 0, 0, 0, honolulu)}
+
+	var pages [][]string
+	for _, departure := range departures {
+		srv, c, auth := newServer(t)
+		srv.AddConnections(hourly(20)...)
+
+		connections, err := c.GetConnections(wien, graz, auth, departure, 14)
+		if err != nil {
+			t.Fatalf("GetConnections() failed: %v", err)
+		}
+		if len(connections) != 14 {
+			t.Fatalf("got %d connections starting at %v, want 14", len(connections), departure)
+		}
+
+		trs, err := srv.TimetableRequests()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var starts []string
+		for _, tr := range trs {
+			starts = append(starts, tr.DatetimeDeparture)
+		}
+		pages = append(pages, starts)
+	}
+
+	// pages start at the last departure of the previous page, regardless of
+	// the time zone of the departure time
+	if fmt.Sprint(pages[0]) != fmt.Sprint(pages[1]) {
+		t.Errorf("requested pages %q starting in %v, want %q as in UTC", pages[1], honolulu, pages[0])
+	}
+}
+
+func TestGetConnectionsDedupe(t *testing.T) {
+	srv, c, auth := newServer(t)
+	// three connections departing at the same time, so every page overlaps
+	// with the previous one
+	srv.AddConnections(
+		connection("a", 0),
+		connection("b", 10),
+		connection("c", 20),
+		connection("d", 20),
+		connection("e", 20),
+		connection("f", 30),
+		connection("g", 40),
+	)
+
+	connections, err := c.GetConnections(wien, graz, auth, start, 7)
+	if err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	assertIDs(t, connections, "a", "b", "c", "d", "e", "f", "g")
+}
+
+func TestGetConnectionsOnlyDuplicates(t *testing.T) {
+	srv, c, auth := newServer(t)
+	// more connections at the same time than fit on a page: the page after
+	// the first one only contains duplicates, so the start time has to be
+	// moved forward
+	var connections []client.Connection
+	for i := 0; i < 6; i++ {
+		connections = append(connections, connection(fmt.Sprintf("same%d", i), 0))
+	}
+	connections = append(connections, connection("later", 5))
+	srv.AddConnections(connections...)
+
+	got, err := c.GetConnections(wien, graz, auth, start, 7)
+	if err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	assertIDs(t, got, "same0", "same1", "same2", "same3", "same4", "same5", "later")
+}
+
+func TestGetConnectionsFewerAvailable(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddConnections(hourly(3)...)
+
+	connections, err := c.GetConnections(wien, graz, auth, start, 5)
+	if err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	assertIDs(t, connections, "c0", "c1", "c2")
+}
+
+func TestGetConnectionsNone(t *testing.T) {
+	_, c, auth := newServer(t)
+
+	connections, err := c.GetConnections(wien, graz, auth, start, 5)
+	if err != nil {
+		t.Fatalf("GetConnections() failed: %v", err)
+	}
+
+	if len(connections) != 0 {
+		t.Errorf("got %d connections, want none", len(connections))
+	}
+}
+
+func TestGetConnectionsDirect(t *testing.T) {
+	srv, c, auth := newServer(t)
+	withChange := connection("change", 0)
+	withChange.Switches = 1
+	srv.AddConnections(withChange, connection("direct", 10))
+
+	opts := client.ConnectionOptions{Direct: true}
+	connections, err := c.GetConnectionsWithOptions(wien, graz, auth, start, 1, opts)
+	if err != nil {
+		t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+	}
+
+	assertIDs(t, connections, "direct")
+}
+
+// withTransfer returns a connection departing minutes after start with a
+// change of change minutes between two trains.
+func withTransfer(id string, minutes, change int) client.Connection {
+	conn := connection(id, minutes)
+	conn.Sections = []client.Section{
+		{
+			From:     client.DepartureStation{Name: wien.Name, Departure: oebbtest.At(minutes)},
+			To:       client.ArrivalStation{Name: "Bruck/Mur", Arrival: oebbtest.At(minutes + 100)},
+			Category: client.Category{DisplayName: "RJ", Number: "553"},
+		},
+		{
+			From:     client.DepartureStation{Name: "Bruck/Mur", Departure: oebbtest.At(minutes + 100 + change)},
+			To:       client.ArrivalStation{Name: graz.Name, Arrival: oebbtest.At(minutes + 155)},
+			Category: client.Category{DisplayName: "REX", Number: "1711"},
+		},
+	}
+	return conn
+}
+
+func TestGetConnectionsConstraints(t *testing.T) {
+	srv, c, auth := newServer(t)
+	var connections []client.Connection
+	for i := 0; i < 20; i++ {
+		change := 2
+		if i%2 == 1 {
+			change = 10
+		}
+		connections = append(connections, withTransfer(fmt.Sprintf("c%d", i), i*30, change))
+	}
+	srv.AddConnections(connections...)
+
+	opts := client.ConnectionOptions{MinTransfer: 5 * time.Minute}
+	got, err := c.GetConnectionsWithOptions(wien, graz, auth, start, 5, opts)
+	if err != nil {
+		t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+	}
+	assertIDs(t, got, "c1", "c3", "c5", "c7", "c9")
+
+	opts = client.ConnectionOptions{MaxTransfers: 1, MaxDuration: 2 * time.Hour}
+	got, err = c.GetConnectionsWithOptions(wien, graz, auth, start, 5, opts)
+	if err != nil {
+		t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got connections %v longer than the maximum duration", ids(got))
+	}
+}
+
+func TestGetConnectionsCategories(t *testing.T) {
+	srv, c, auth := newServer(t)
+	bus := withTransfer("bus", 0, 5)
+	bus.Sections[1].Category = client.Category{DisplayName: "Bus", Number: "270"}
+	rex := withTransfer("rex", 10, 5)
+	rj := connection("rj", 20)
+	rj.Sections = []client.Section{{Category: client.Category{ShortName: "RJ", DisplayName: "RJ", Number: "555"}}}
+	srv.AddConnections(bus, rex, rj)
+
+	tests := []struct {
+		opts client.ConnectionOptions
+		want []string
+	}{
+		{client.ConnectionOptions{Exclude: []string{"bus"}}, []string{"rex", "rj"}},
+		{client.ConnectionOptions{Only: []string{"rj", "ice"}}, []string{"rj"}},
+		{client.ConnectionOptions{Only: []string{"RJ", "REX"}, Exclude: []string{"ICE"}}, []string{"rex", "rj"}},
+	}
+	for _, tt := range tests {
+		connections, err := c.GetConnectionsWithOptions(wien, graz, auth, start, 3, tt.opts)
+		if err != nil {
+			t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+		}
+		assertIDs(t, connections, tt.want...)
+	}
+}
+
+func TestGetConnectionsRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     client.ConnectionOptions
+		filter   []string
+		sortType string
+		flags    []string
+	}{
+		{"defaults", client.ConnectionOptions{}, nil, client.SortDeparture, nil},
+		{"direct", client.ConnectionOptions{Direct: true}, []string{"direct"}, client.SortDeparture, nil},
+		{"min transfer", client.ConnectionOptions{MinTransfer: 10 * time.Minute}, []string{"changeTime"}, client.SortDeparture, nil},
+		// the trains filter of the API would also drop trams and ferries
+		{"exclude bus", client.ConnectionOptions{Exclude: []string{"Bus"}}, nil, client.SortDeparture, nil},
+		{"include cancelled", client.ConnectionOptions{IncludeCancelled: true}, []string{"droppedConnections"}, client.SortDeparture, nil},
+		{"sort by arrival", client.ConnectionOptions{SortType: client.SortArrival}, nil, client.SortArrival, nil},
+		{
+			"accessibility",
+			client.ConnectionOptions{Accessibility: client.Accessibility{Wheelchair: true, AssistanceDog: true}},
+			[]string{"wheelchair"},
+			client.SortDeparture,
+			[]string{"hasWheelchair", "hasAssistanceDog"},
+		},
+		{
+			"passes",
+			client.ConnectionOptions{Accessibility: client.Accessibility{HandicappedPass: true, Attendant: true}},
+			nil,
+			client.SortDeparture,
+			[]string{"hasHandicappedPass", "hasAttendant"},
+		},
+	}
+
+	contains := func(list []string, s string) bool {
+		for _, l := range list {
+			if l == s {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, c, auth := newServer(t)
+			srv.AddConnections(hourly(1)...)
+
+			if _, err := c.GetConnectionsWithOptions(wien, graz, auth, start, 1, tt.opts); err != nil {
+				t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+			}
+
+			trs, err := srv.TimetableRequests()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := trs[0]
+
+			for name, set := range tr.Filter {
+				if want := contains(tt.filter, name); set != want {
+					t.Errorf("filter %s is %v, want %v", name, set, want)
+				}
+			}
+			if tr.SortType != tt.sortType {
+				t.Errorf("requested sort type %q, want %q", tr.SortType, tt.sortType)
+			}
+			if len(tr.Passengers) != 1 {
+				t.Fatalf("got %d passengers, want 1", len(tr.Passengers))
+			}
+			for name, set := range tr.Passengers[0].ChallengedFlags {
+				if want := contains(tt.flags, name); set != want {
+					t.Errorf("challenged flag %s is %v, want %v", name, set, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetConnectionsParallel(t *testing.T) {
+	// six connections in the first hour, then a gap of a day
+	var sparse []client.Connection
+	for i := 0; i < 20; i++ {
+		minutes := i * 10
+		if i >= 6 {
+			minutes = 24*60 + i*60
+		}
+		sparse = append(sparse, connection(fmt.Sprintf("c%d", i), minutes))
+	}
+
+	tests := []struct {
+		name        string
+		connections []client.Connection
+	}{
+		{"regular", hourly(30)},
+		{"sparse", sparse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, c, auth := newServer(t)
+			srv.AddConnections(tt.connections...)
+			c.Concurrency = 4
+
+			connections, err := c.GetConnections(wien, graz, auth, start, 20)
+			if err != nil {
+				t.Fatalf("GetConnections() failed: %v", err)
+			}
+
+			var want []string
+			for i := 0; i < 20; i++ {
+				want = append(want, fmt.Sprintf("c%d", i))
+			}
+			assertIDs(t, connections, want...)
+		})
+	}
+}
+
+func TestGetConnectionsParallelSortArrival(t *testing.T) {
+	srv, c, auth := newServer(t)
+	all := hourly(30)
+	// sorted by arrival, every page happens to be in reverse order of
+	// departure
+	srv.HandleTimetable(func(tr oebbtest.TimetableRequest) []client.Connection {
+		departure, _ := tr.Departure()
+		var page []client.Connection
+		for _, conn := range all {
+			if dep, _ := client.ParseTime(conn.From.Departure); !dep.Before(departure) && len(page) < tr.Count {
+				page = append([]client.Connection{conn}, page...)
+			}
+		}
+		return page
+	})
+	c.Concurrency = 4
+
+	opts := client.ConnectionOptions{SortType: client.SortArrival}
+	connections, err := c.GetConnectionsWithOptions(wien, graz, auth, start, 20, opts)
+	if err != nil {
+		t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+	}
+
+	// pages keep the order of the API and continue after the latest
+	// departure of the previous page
+	assertIDs(t, connections,
+		"c5", "c4", "c3", "c2", "c1", "c0",
+		"c10", "c9", "c8", "c7", "c6",
+		"c15", "c14", "c13", "c12", "c11",
+		"c20", "c19", "c18", "c17")
+}
+
+func TestGetConnectionsFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure oebbtest.Failure
+		check   func(error) bool
+	}{
+		{"session timeout", oebbtest.SessionTimeout, func(err error) bool {
+			var timeoutErr *client.SessionTimeoutError
+			return errors.As(err, &timeoutErr)
+		}},
+		{"server error", oebbtest.ServerError, func(err error) bool {
+			var statusErr *client.StatusError
+			return errors.As(err, &statusErr) && statusErr.StatusCode == 500
+		}},
+		{"malformed json", oebbtest.MalformedJSON, func(err error) bool {
+			return err != nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, c, auth := newServer(t)
+			srv.AddConnections(hourly(10)...)
+			srv.Fail(oebbtest.EndpointTimetable, tt.failure)
+
+			_, err := c.GetConnections(wien, graz, auth, start, 10)
+			if !tt.check(err) {
+				t.Errorf("got unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestGetConnectionsExpiredSession(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddConnections(hourly(3)...)
+	srv.ExpireSession()
+
+	_, err := c.GetConnections(wien, graz, auth, start, 3)
+	var timeoutErr *client.SessionTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("got error %v, want a session timeout", err)
+	}
+
+	auth, err = c.Auth()
+	if err != nil {
+		t.Fatalf("Auth() failed: %v", err)
+	}
+
+	connections, err := c.GetConnections(wien, graz, auth, start, 3)
+	if err != nil {
+		t.Fatalf("GetConnections() failed after authenticating again: %v", err)
+	}
+	assertIDs(t, connections, "c0", "c1", "c2")
+}
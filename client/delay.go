@@ -0,0 +1,74 @@
+package client
+
+import "time"
+
+// timeLayout is the format of times in API requests and responses.
+const timeLayout = "2006-01-02T15:04:05.999"
+
+// ParseTime parses a time of an API response. The API's times carry no time
+// zone, so the result is the wall-clock time of the timetable, labelled UTC.
+func ParseTime(str string) (time.Time, error) {
+	return time.Parse(timeLayout, str)
+}
+
+// FormatTime formats the wall-clock time of t like the times of the API.
+func FormatTime(t time.Time) string {
+	return t.Format(timeLayout)
+}
+
+// delay returns the difference between the actual and the scheduled time, or
+// zero if there is no real-time information.
+func delay(scheduled, actual string) (time.Duration, error) {
+	if actual == "" {
+		return 0, nil
+	}
+
+	s, err := ParseTime(scheduled)
+	if err != nil {
+		return 0, err
+	}
+
+	a, err := ParseTime(actual)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.Sub(s), nil
+}
+
+// Delay returns how much later than scheduled the departure is expected.
+func (s DepartureStation) Delay() (time.Duration, error) {
+	return delay(s.Departure, s.DepartureDelay)
+}
+
+// PlatformChanged reports whether the train departs from a different platform
+// than scheduled.
+func (s DepartureStation) PlatformChanged() bool {
+	return platformChanged(s.DeparturePlatform, s.DeparturePlatformDeviation)
+}
+
+// Delay returns how much later than scheduled the arrival is expected.
+func (s ArrivalStation) Delay() (time.Duration, error) {
+	return delay(s.Arrival, s.ArrivalDelay)
+}
+
+// PlatformChanged reports whether the train arrives at a different platform
+// than scheduled.
+func (s ArrivalStation) PlatformChanged() bool {
+	return platformChanged(s.ArrivalPlatform, s.ArrivalPlatformDeviation)
+}
+
+// Delay returns how much later than scheduled the departure is expected.
+func (d Departure) Delay() (time.Duration, error) {
+	return delay(d.Departure, d.DepartureDelay)
+}
+
+// PlatformChanged reports whether the train departs from a different platform
+// than scheduled.
+func (d Departure) PlatformChanged() bool {
+	return platformChanged(d.DeparturePlatform, d.DeparturePlatformDeviation)
+}
+
+func platformChanged(scheduled, deviation string) bool {
+	return deviation != "" && deviation != scheduled
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// departuresPath is the endpoint of departure boards. It has not been checked
+// against a recorded response: the path, the station, datetimeDeparture and
+// count parameters and the response fields are modelled on the timetable
+// endpoint. Request /departures from "oebb-cli --record cassette.json serve"
+// to record a departure board and verify them.
+const departuresPath = "/api/hafas/v1/departures"
+
+// Departure is a train or bus leaving a station, as shown on a departure
+// board.
+type Departure struct {
+	Category Category `json:"category"`
+	// Direction is the final destination of the train.
+	Direction                  string `json:"direction"`
+	Departure                  string `json:"departure"`
+	DepartureDelay             string `json:"departureDelay"`
+	DeparturePlatform          string `json:"departurePlatform"`
+	DeparturePlatformDeviation string `json:"departurePlatformDeviation"`
+	HasRealtime                bool   `json:"hasRealtime"`
+	Cancelled                  bool   `json:"cancelled"`
+}
+
+type departuresResponse struct {
+	Departures []Departure `json:"departures"`
+}
+
+// GetDepartures returns up to maxResults departures from a station at or
+// after departureTime, earliest first.
+func GetDepartures(station Station, a AuthInfo, departureTime time.Time, maxResults int) ([]Departure, error) {
+	return DefaultClient.GetDepartures(station, a, departureTime, maxResults)
+}
+
+// GetDepartures returns the departures from a station, see the package-level
+// GetDepartures.
+func (c *Client) GetDepartures(station Station, a AuthInfo, departureTime time.Time, maxResults int) ([]Departure, error) {
+	query := url.Values{}
+	query.Set("station", strconv.Itoa(station.Number))
+	query.Set("datetimeDeparture", FormatTime(departureTime))
+	query.Set("count", strconv.Itoa(maxResults))
+
+	req, err := c.newRequest("GET", departuresPath+"?"+query.Encode(), nil, a)
+	if err != nil {
+		return nil, err
+	}
+
+	key := departuresCacheKey(station, departureTime, maxResults)
+	departures := &departuresResponse{}
+	if err := c.doCached(req, timetablesCache, key, departures); err != nil {
+		return nil, err
+	}
+
+	return departures.Departures, nil
+}
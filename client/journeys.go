@@ -0,0 +1,113 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// journeysPath is the endpoint of train journeys. Like departuresPath, it has
+// not been checked against a recorded response: the path, the category,
+// number and date parameters and the response fields are modelled on the
+// station and departure endpoints. Record a lookup with
+// "oebb-cli --record cassette.json train RJX160" to verify them.
+const journeysPath = "/api/hafas/v1/journeys"
+
+// Stop is a station a train stops at on its journey. The first stop has no
+// arrival and the last stop no departure.
+type Stop struct {
+	Name                       string `json:"name"`
+	Esn                        int    `json:"esn"`
+	Arrival                    string `json:"arrival"`
+	ArrivalDelay               string `json:"arrivalDelay"`
+	ArrivalPlatform            string `json:"arrivalPlatform"`
+	ArrivalPlatformDeviation   string `json:"arrivalPlatformDeviation"`
+	Departure                  string `json:"departure"`
+	DepartureDelay             string `json:"departureDelay"`
+	DeparturePlatform          string `json:"departurePlatform"`
+	DeparturePlatformDeviation string `json:"departurePlatformDeviation"`
+	// Cancelled is set if the train does not stop here.
+	Cancelled bool `json:"cancelled"`
+}
+
+// AsArrival returns the arrival at the stop, e.g. to compute its delay.
+func (s Stop) AsArrival() ArrivalStation {
+	return ArrivalStation{
+		Name:                     s.Name,
+		Esn:                      s.Esn,
+		Arrival:                  s.Arrival,
+		ArrivalDelay:             s.ArrivalDelay,
+		ArrivalPlatform:          s.ArrivalPlatform,
+		ArrivalPlatformDeviation: s.ArrivalPlatformDeviation,
+	}
+}
+
+// AsDeparture returns the departure from the stop, e.g. to compute its delay.
+func (s Stop) AsDeparture() DepartureStation {
+	return DepartureStation{
+		Name:                       s.Name,
+		Esn:                        s.Esn,
+		Departure:                  s.Departure,
+		DepartureDelay:             s.DepartureDelay,
+		DeparturePlatform:          s.DeparturePlatform,
+		DeparturePlatformDeviation: s.DeparturePlatformDeviation,
+	}
+}
+
+// Journey is the run of a train on a certain day, with all of its stops.
+type Journey struct {
+	Category Category `json:"category"`
+	// Direction is the final destination of the train.
+	Direction   string `json:"direction"`
+	Stops       []Stop `json:"stops"`
+	HasRealtime bool   `json:"hasRealtime"`
+	Cancelled   bool   `json:"cancelled"`
+	Notes       []Note `json:"notes"`
+}
+
+type journeysResponse struct {
+	Journeys []Journey `json:"journeys"`
+}
+
+var trainPattern = regexp.MustCompile(`^\s*([A-Za-z]+)\s*(\d+)\s*$`)
+
+// ParseTrain splits a train name such as "RJX 160" or "nj466" into its
+// category and number. The category is returned in upper case.
+func ParseTrain(name string) (category, number string, err error) {
+	m := trainPattern.FindStringSubmatch(name)
+	if m == nil {
+		return "", "", fmt.Errorf("invalid train %q, expected a category and a number like \"RJX 160\"", name)
+	}
+	return strings.ToUpper(m[1]), m[2], nil
+}
+
+// GetJourneys returns the journeys of the train with the given category and
+// number running on the day of date. Usually there is a single journey, but
+// some train numbers are used by several operators.
+func GetJourneys(category, number string, a AuthInfo, date time.Time) ([]Journey, error) {
+	return DefaultClient.GetJourneys(category, number, a, date)
+}
+
+// GetJourneys returns the journeys of a train, see the package-level
+// GetJourneys.
+func (c *Client) GetJourneys(category, number string, a AuthInfo, date time.Time) ([]Journey, error) {
+	query := url.Values{}
+	query.Set("category", category)
+	query.Set("number", number)
+	query.Set("date", date.Format("2006-01-02"))
+
+	req, err := c.newRequest("GET", journeysPath+"?"+query.Encode(), nil, a)
+	if err != nil {
+		return nil, err
+	}
+
+	key := journeysCacheKey(category, number, date)
+	journeys := &journeysResponse{}
+	if err := c.doCached(req, timetablesCache, key, journeys); err != nil {
+		return nil, err
+	}
+
+	return journeys.Journeys, nil
+}
@@ -0,0 +1,110 @@
+package client_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+func TestParseTrain(t *testing.T) {
+	tests := []struct {
+		name, category, number string
+	}{
+		{"RJX 160", "RJX", "160"},
+		{"nj466", "NJ", "466"},
+		{" S 1 ", "S", "1"},
+	}
+	for _, tt := range tests {
+		category, number, err := client.ParseTrain(tt.name)
+		if err != nil || category != tt.category || number != tt.number {
+			t.Errorf("ParseTrain(%q) = %q, %q, %v, want %q, %q", tt.name, category, number, err, tt.category, tt.number)
+		}
+	}
+
+	for _, invalid := range []string{"", "160", "RJX", "RJX 160 161"} {
+		if _, _, err := client.ParseTrain(invalid); err == nil {
+			t.Errorf("ParseTrain(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+// journeysResponse is a response of the journeys endpoint in the format
+// assumed by the client, see journeysPath.
+const journeysResponse = `{
+	"journeys": [{
+		"category": {"name": "RJX", "displayName": "RJX", "number": "160"},
+		"direction": "Zürich HB",
+		"hasRealtime": true,
+		"stops": [
+			{"name": "Wien Hbf", "departure": "2020-05-04T08:00:00.000", "departureDelay": "2020-05-04T08:03:00.000", "departurePlatform": "8"},
+			{"name": "St. Pölten Hbf", "arrival": "2020-05-04T08:25:00.000", "departure": "2020-05-04T08:27:00.000", "cancelled": true},
+			{"name": "Zürich HB", "arrival": "2020-05-04T16:20:00.000", "arrivalPlatform": "11"}
+		]
+	}]
+}`
+
+func TestGetJourneys(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/hafas/v1/journeys" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		fmt.Fprint(w, journeysResponse)
+	}))
+	defer srv.Close()
+
+	c := &client.Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+	journeys, err := c.GetJourneys("RJX", "160", client.AuthInfo{}, start)
+	if err != nil {
+		t.Fatalf("GetJourneys() failed: %v", err)
+	}
+
+	if query.Get("category") != "RJX" || query.Get("number") != "160" || query.Get("date") != "2020-05-04" {
+		t.Errorf("got query %v, want category, number and date", query)
+	}
+
+	if len(journeys) != 1 || len(journeys[0].Stops) != 3 {
+		t.Fatalf("got journeys %+v, want one journey with 3 stops", journeys)
+	}
+	j := journeys[0]
+	if j.Direction != "Zürich HB" || j.Category.Number != "160" || !j.HasRealtime {
+		t.Errorf("got journey %+v", j)
+	}
+	if delay, err := j.Stops[0].AsDeparture().Delay(); err != nil || delay != 3*time.Minute {
+		t.Errorf("got departure delay %v, %v, want 3m", delay, err)
+	}
+	if !j.Stops[1].Cancelled || j.Stops[2].AsArrival().ArrivalPlatform != "11" {
+		t.Errorf("got stops %+v", j.Stops)
+	}
+}
+
+func TestGetJourneysDate(t *testing.T) {
+	srv, c, auth := newServer(t)
+	today := client.Journey{
+		Category: client.Category{DisplayName: "RJX", Number: "160"},
+		Stops:    []client.Stop{{Name: wien.Name, Departure: oebbtest.At(0)}},
+	}
+	tomorrow := today
+	tomorrow.Stops = []client.Stop{{Name: wien.Name, Departure: client.FormatTime(start.AddDate(0, 0, 1))}}
+	srv.AddJourneys(today, tomorrow)
+
+	journeys, err := c.GetJourneys("RJX", "160", auth, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetJourneys() failed: %v", err)
+	}
+	if len(journeys) != 1 || journeys[0].Stops[0].Departure != tomorrow.Stops[0].Departure {
+		t.Errorf("got journeys %+v, want the journey of the requested day", journeys)
+	}
+
+	if journeys, err := c.GetJourneys("RJ", "160", auth, start); err != nil || len(journeys) != 0 {
+		t.Errorf("got journeys %+v, %v for an unknown train, want none", journeys, err)
+	}
+}
@@ -0,0 +1,25 @@
+package client
+
+// Logger receives diagnostic events from a Client, such as requests sent to
+// the API along with their status and latency. An event consists of a message
+// and alternating key/value pairs describing it.
+//
+// Session tokens are never passed to a Logger.
+type Logger interface {
+	Log(msg string, keyvals ...interface{})
+}
+
+// LoggerFunc adapts an ordinary function to the Logger interface.
+type LoggerFunc func(msg string, keyvals ...interface{})
+
+// Log calls f(msg, keyvals...).
+func (f LoggerFunc) Log(msg string, keyvals ...interface{}) {
+	f(msg, keyvals...)
+}
+
+// log passes an event to the logger of the client, if there is one.
+func (c *Client) log(msg string, keyvals ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Log(msg, keyvals...)
+	}
+}
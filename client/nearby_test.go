@@ -0,0 +1,75 @@
+package client_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+var (
+	wienHbf   = client.Station{Name: "Wien Hbf", Number: 1290401, Latitude: 48185184, Longitude: 16376413}
+	belvedere = client.Station{Name: "Wien Quartier Belvedere", Number: 1290501, Latitude: 48191512, Longitude: 16379393}
+	meidling  = client.Station{Name: "Wien Meidling", Number: 1191201, Latitude: 48174620, Longitude: 16333580}
+	grazHbf   = client.Station{Name: "Graz Hbf", Number: 8100173, Latitude: 47072712, Longitude: 15416850}
+)
+
+func TestDistanceTo(t *testing.T) {
+	tests := []struct {
+		station  client.Station
+		lat, lon float64
+		want     float64
+	}{
+		{wienHbf, 48.185184, 16.376413, 0},
+		{wienHbf, 48.191512, 16.379393, 735},
+		{wienHbf, 47.072712, 15.416850, 144300},
+	}
+
+	for _, tt := range tests {
+		// allow for 1% of error, e.g. from the radius of the earth
+		if got := tt.station.DistanceTo(tt.lat, tt.lon); math.Abs(got-tt.want) > tt.want/100+1 {
+			t.Errorf("distance from %s to %f,%f = %.0f m, want %.0f m", tt.station.Name, tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestGetNearbyStations(t *testing.T) {
+	srv, c, auth := newServer(t)
+	// the API returns stations outside of the radius and in any order
+	srv.AddNearbyStations(grazHbf, meidling, belvedere, wienHbf)
+
+	tests := []struct {
+		radius, max int
+		want        []string
+	}{
+		{1000, 10, []string{"Wien Hbf", "Wien Quartier Belvedere"}},
+		{5000, 10, []string{"Wien Hbf", "Wien Quartier Belvedere", "Wien Meidling"}},
+		{5000, 2, []string{"Wien Hbf", "Wien Quartier Belvedere"}},
+		{5000, 0, []string{"Wien Hbf", "Wien Quartier Belvedere", "Wien Meidling"}},
+		{1, 10, nil},
+	}
+
+	for _, tt := range tests {
+		stations, err := c.GetNearbyStations(48.1852, 16.3764, tt.radius, tt.max, auth)
+		if err != nil {
+			t.Fatalf("GetNearbyStations() failed: %v", err)
+		}
+		got := names(stations)
+		if len(got) != len(tt.want) {
+			t.Errorf("radius %d, max %d: got %q, want %q", tt.radius, tt.max, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("radius %d, max %d: got %q, want %q", tt.radius, tt.max, got, tt.want)
+				break
+			}
+		}
+	}
+
+	query := srv.Requests(oebbtest.EndpointNearby)[0].Query
+	if query.Get("latitude") != "48185200" || query.Get("longitude") != "16376400" || query.Get("radius") != "1000" || query.Get("count") != "10" {
+		t.Errorf("got query %v, want the coordinate in millionths of a degree", query)
+	}
+}
@@ -0,0 +1,46 @@
+package client
+
+import "strings"
+
+// NoteSeverity is how important a note is for travellers.
+type NoteSeverity string
+
+// The severities of notes, from least to most important.
+const (
+	NoteInfo     NoteSeverity = "info"
+	NoteWarning  NoteSeverity = "warning"
+	NoteCritical NoteSeverity = "critical"
+)
+
+// Note is a message about a connection or a section, either a disruption
+// notice (HIM message) such as construction works or rail replacement
+// services, or a remark such as "reservation required" or "bikes not
+// allowed".
+type Note struct {
+	// Type is "him" for disruption notices and "remark" for remarks.
+	Type     string           `json:"type"`
+	Code     string           `json:"code"`
+	Severity NoteSeverity     `json:"severity"`
+	Title    TranslatedString `json:"title"`
+	Text     TranslatedString `json:"text"`
+}
+
+// In returns the string in the language with the given ISO 639-1 code, e.g.
+// "en". If there is no translation, it falls back to English and then to
+// German.
+func (s TranslatedString) In(lang string) string {
+	var str string
+	switch strings.ToLower(lang) {
+	case "de":
+		str = s.De
+	case "it":
+		str = s.It
+	}
+	if str == "" {
+		str = s.En
+	}
+	if str == "" {
+		str = s.De
+	}
+	return str
+}
@@ -0,0 +1,35 @@
+package client_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chrboe/oebb/client"
+)
+
+func TestNotes(t *testing.T) {
+	body := `{"notes": [{"type": "him", "severity": "critical", "text": {"de": "Schienenersatzverkehr", "en": "Rail replacement service"}}],
+		"sections": [{"notes": [{"type": "remark", "code": "FK", "severity": "info", "text": {"de": "Fahrradmitnahme reservierungspflichtig"}}]}]}`
+
+	var conn client.Connection
+	if err := json.Unmarshal([]byte(body), &conn); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conn.Notes) != 1 || conn.Notes[0].Severity != client.NoteCritical {
+		t.Fatalf("got connection notes %+v", conn.Notes)
+	}
+	if got := conn.Notes[0].Text.In("de"); got != "Schienenersatzverkehr" {
+		t.Errorf("got German text %q", got)
+	}
+	if got := conn.Notes[0].Text.In("it"); got != "Rail replacement service" {
+		t.Errorf("got text %q without Italian translation, want English", got)
+	}
+
+	if len(conn.Sections[0].Notes) != 1 || conn.Sections[0].Notes[0].Code != "FK" {
+		t.Fatalf("got section notes %+v", conn.Sections[0].Notes)
+	}
+	if got := conn.Sections[0].Notes[0].Text.In("en"); got != "Fahrradmitnahme reservierungspflichtig" {
+		t.Errorf("got text %q without English translation, want German", got)
+	}
+}
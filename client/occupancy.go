@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Occupancy is the expected load of a train.
+type Occupancy int
+
+// The occupancy levels, from least to most crowded.
+const (
+	OccupancyUnknown Occupancy = iota
+	OccupancyLow
+	OccupancyMedium
+	OccupancyHigh
+	OccupancyFull
+)
+
+var occupancyNames = []string{"unknown", "low", "medium", "high", "full"}
+
+func (o Occupancy) String() string {
+	if o < 0 || int(o) >= len(occupancyNames) {
+		return fmt.Sprintf("Occupancy(%d)", int(o))
+	}
+	return occupancyNames[o]
+}
+
+// ParseOccupancy returns the occupancy level with the given name, as
+// returned by Occupancy.String.
+func ParseOccupancy(name string) (Occupancy, error) {
+	for i, n := range occupancyNames {
+		if strings.EqualFold(name, n) {
+			return Occupancy(i), nil
+		}
+	}
+	return OccupancyUnknown, fmt.Errorf("invalid occupancy %q", name)
+}
+
+// UnmarshalJSON accepts the levels as numbers from 0 (unknown) to 4 (full),
+// as returned by the API, or by name.
+func (o *Occupancy) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		if n < 0 || n >= len(occupancyNames) {
+			n = int(OccupancyUnknown)
+		}
+		*o = Occupancy(n)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	if level, err := ParseOccupancy(name); err == nil {
+		*o = level
+	} else {
+		*o = OccupancyUnknown
+	}
+	return nil
+}
+
+// ClassOccupancy is the expected load of the first and second class of a
+// train.
+type ClassOccupancy struct {
+	First  Occupancy `json:"first"`
+	Second Occupancy `json:"second"`
+}
+
+// Class returns the occupancy of the first class if firstClass is set, and of
+// the second class otherwise.
+func (o ClassOccupancy) Class(firstClass bool) Occupancy {
+	if firstClass {
+		return o.First
+	}
+	return o.Second
+}
+
+// Occupancy returns the highest expected occupancy of the trains of the
+// connection in the given class. Trains without occupancy information are
+// ignored.
+func (c Connection) Occupancy(firstClass bool) Occupancy {
+	max := OccupancyUnknown
+	for _, s := range c.Sections {
+		if o := s.Occupancy.Class(firstClass); o > max {
+			max = o
+		}
+	}
+	return max
+}
+
+// ByOccupancy ranks connections by their occupancy in the given class, least
+// crowded first. Connections without occupancy information rank like a
+// medium occupancy.
+func ByOccupancy(firstClass bool) Less {
+	level := func(c Connection) Occupancy {
+		if o := c.Occupancy(firstClass); o != OccupancyUnknown {
+			return o
+		}
+		return OccupancyMedium
+	}
+	return func(a, b Connection) bool {
+		return level(a) < level(b)
+	}
+}
@@ -0,0 +1,53 @@
+package client_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chrboe/oebb/client"
+)
+
+func TestOccupancy(t *testing.T) {
+	var s client.Section
+	if err := json.Unmarshal([]byte(`{"occupancy": {"first": 1, "second": "HIGH"}}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Occupancy.First != client.OccupancyLow || s.Occupancy.Second != client.OccupancyHigh {
+		t.Errorf("got occupancy %v/%v, want low/high", s.Occupancy.First, s.Occupancy.Second)
+	}
+
+	if err := json.Unmarshal([]byte(`{"occupancy": {"first": 9, "second": "packed"}}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Occupancy.First != client.OccupancyUnknown || s.Occupancy.Second != client.OccupancyUnknown {
+		t.Errorf("got occupancy %v/%v for invalid levels, want unknown", s.Occupancy.First, s.Occupancy.Second)
+	}
+}
+
+func TestGetConnectionsOccupancy(t *testing.T) {
+	srv, c, auth := newServer(t)
+	full := withTransfer("full", 0, 5)
+	full.Sections[1].Occupancy = client.ClassOccupancy{First: client.OccupancyLow, Second: client.OccupancyFull}
+	medium := withTransfer("medium", 10, 5)
+	medium.Sections[0].Occupancy = client.ClassOccupancy{First: client.OccupancyHigh, Second: client.OccupancyMedium}
+	unknown := withTransfer("unknown", 20, 5)
+	srv.AddConnections(full, medium, unknown)
+
+	opts := client.ConnectionOptions{MaxOccupancy: client.OccupancyMedium}
+	connections, err := c.GetConnectionsWithOptions(wien, graz, auth, start, 3, opts)
+	if err != nil {
+		t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+	}
+	assertIDs(t, connections, "medium", "unknown")
+
+	opts.FirstClass = true
+	connections, err = c.GetConnectionsWithOptions(wien, graz, auth, start, 3, opts)
+	if err != nil {
+		t.Fatalf("GetConnectionsWithOptions() failed: %v", err)
+	}
+	assertIDs(t, connections, "full", "unknown")
+
+	connections = []client.Connection{full, medium, unknown}
+	client.SortConnections(connections, client.ByOccupancy(false))
+	assertIDs(t, connections, "medium", "unknown", "full")
+}
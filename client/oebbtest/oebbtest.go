@@ -0,0 +1,491 @@
+// Package oebbtest provides a fake ÖBB Tickets API for testing code which
+// uses the client package without network access.
+//
+// The fake serves the authentication, station, nearby station, timetable,
+// departure and journey endpoints from programmable fixtures, can be told to
+// fail in the ways the real API does and records all requests it receives so
+// tests can make assertions about them.
+package oebbtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+)
+
+// Endpoint identifies one of the faked API endpoints by its path.
+type Endpoint string
+
+// The endpoints served by Server.
+const (
+	EndpointAuth       Endpoint = "/api/domain/v3/init"
+	EndpointStations   Endpoint = "/api/hafas/v1/stations"
+	EndpointNearby     Endpoint = "/api/hafas/v1/stations/nearby"
+	EndpointTimetable  Endpoint = "/api/hafas/v4/timetable"
+	EndpointDepartures Endpoint = "/api/hafas/v1/departures"
+	EndpointJourneys   Endpoint = "/api/hafas/v1/journeys"
+)
+
+// Failure is an error condition Server can be told to produce.
+type Failure int
+
+const (
+	// SessionTimeout responds with status 440, which the API uses for
+	// expired sessions.
+	SessionTimeout Failure = iota + 1
+	// ServerError responds with status 500.
+	ServerError
+	// MalformedJSON responds with status 200 and a body which is not valid
+	// JSON.
+	MalformedJSON
+)
+
+// Start is a Monday morning, which At counts from.
+var Start = time.Date(2020, 5, 4, 8, 0, 0, 0, time.UTC)
+
+// At returns the time minutes after Start, formatted like the times of API
+// responses.
+func At(minutes int) string {
+	return client.FormatTime(Start.Add(time.Duration(minutes) * time.Minute))
+}
+
+// Request is a request received by Server.
+type Request struct {
+	Endpoint Endpoint
+	Method   string
+	Header   http.Header
+	Query    url.Values
+	Body     []byte
+}
+
+// TimetableRequest is the decoded body of a request to EndpointTimetable.
+type TimetableRequest struct {
+	DatetimeDeparture string          `json:"datetimeDeparture"`
+	Count             int             `json:"count"`
+	SortType          string          `json:"sortType"`
+	Filter            map[string]bool `json:"filter"`
+	Passengers        []Passenger     `json:"passengers"`
+	From              client.Station  `json:"from"`
+	To                client.Station  `json:"to"`
+}
+
+// Passenger is a passenger of a timetable request.
+type Passenger struct {
+	Type            string          `json:"type"`
+	ChallengedFlags map[string]bool `json:"challengedFlags"`
+}
+
+// Departure returns the parsed departure time of the request.
+func (r TimetableRequest) Departure() (time.Time, error) {
+	return client.ParseTime(r.DatetimeDeparture)
+}
+
+// Timetable decodes the body of a timetable request.
+func (r Request) Timetable() (TimetableRequest, error) {
+	var tr TimetableRequest
+	if r.Endpoint != EndpointTimetable {
+		return tr, fmt.Errorf("not a timetable request: %s", r.Endpoint)
+	}
+	err := json.Unmarshal(r.Body, &tr)
+	return tr, err
+}
+
+// TimetableFunc computes the connections returned for a timetable request.
+type TimetableFunc func(req TimetableRequest) []client.Connection
+
+// Server is a fake ÖBB Tickets API. Create it using NewServer and Close it
+// when done.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	session     int
+	stations    map[string][]client.Station
+	nearby      []client.Station
+	connections []client.Connection
+	departures  map[int][]client.Departure
+	journeys    map[string][]client.Journey
+	timetable   TimetableFunc
+	failures    map[Endpoint][]Failure
+	requests    []Request
+}
+
+// NewServer starts a fake API without any fixtures.
+func NewServer() *Server {
+	s := &Server{
+		stations:   map[string][]client.Station{},
+		departures: map[int][]client.Departure{},
+		journeys:   map[string][]client.Journey{},
+		failures:   map[Endpoint][]Failure{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(string(EndpointAuth), s.handleAuth)
+	mux.HandleFunc(string(EndpointStations), s.handleStations)
+	mux.HandleFunc(string(EndpointNearby), s.handleNearby)
+	mux.HandleFunc(string(EndpointTimetable), s.handleTimetable)
+	mux.HandleFunc(string(EndpointDepartures), s.handleDepartures)
+	mux.HandleFunc(string(EndpointJourneys), s.handleJourneys)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Client returns a client which sends its requests to the fake.
+func (s *Server) Client() *client.Client {
+	return &client.Client{
+		BaseURL:    s.URL,
+		HTTPClient: s.Server.Client(),
+	}
+}
+
+// AddStations sets the stations returned when looking up query. Queries are
+// matched case-insensitively, unknown queries return no stations.
+func (s *Server) AddStations(query string, stations ...client.Station) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stations[strings.ToLower(query)] = stations
+}
+
+// AddNearbyStations adds stations returned by nearby station requests. Like
+// the real API, the fake returns all of them regardless of the requested
+// coordinate, radius and count, in the order they were added.
+func (s *Server) AddNearbyStations(stations ...client.Station) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nearby = append(s.nearby, stations...)
+}
+
+// AddConnections adds connections to the timetable. Unless a TimetableFunc
+// is set, timetable requests are answered with the connections departing at
+// or after the requested time, earliest first, limited to the requested
+// count. The stations of the request are not taken into account.
+func (s *Server) AddConnections(connections ...client.Connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connections = append(s.connections, connections...)
+}
+
+// AddDepartures adds departures to the departure board of the station with
+// the given number. Requests are answered with the departures at or after the
+// requested time, earliest first, limited to the requested count.
+func (s *Server) AddDepartures(station int, departures ...client.Departure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.departures[station] = append(s.departures[station], departures...)
+}
+
+// AddJourneys adds journeys of trains. Journey requests are answered with the
+// journeys of the requested category and number whose first departure is on
+// the requested date.
+func (s *Server) AddJourneys(journeys ...client.Journey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range journeys {
+		key := strings.ToUpper(j.Category.DisplayName) + " " + j.Category.Number
+		s.journeys[key] = append(s.journeys[key], j)
+	}
+}
+
+// HandleTimetable replaces the default timetable behaviour with f.
+func (s *Server) HandleTimetable(f TimetableFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timetable = f
+}
+
+// Fail makes the next requests to e fail, one request per given failure, in
+// order. Subsequent requests are served normally again.
+func (s *Server) Fail(e Endpoint, failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[e] = append(s.failures[e], failures...)
+}
+
+// ExpireSession invalidates the current session. Requests using it fail with
+// a session timeout until a client authenticates again.
+func (s *Server) ExpireSession() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.session++
+}
+
+// Requests returns all requests received for e, in order.
+func (s *Server) Requests(e Endpoint) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for _, r := range s.requests {
+		if r.Endpoint == e {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// TimetableRequests returns the decoded bodies of all timetable requests, in
+// order.
+func (s *Server) TimetableRequests() ([]TimetableRequest, error) {
+	var trs []TimetableRequest
+	for _, r := range s.Requests(EndpointTimetable) {
+		tr, err := r.Timetable()
+		if err != nil {
+			return nil, err
+		}
+		trs = append(trs, tr)
+	}
+	return trs, nil
+}
+
+func (s *Server) accessToken() string {
+	return fmt.Sprintf("oebbtest-token-%d", s.session)
+}
+
+// record stores the request and returns its body along with the failure to
+// produce for it, if any. It also produces a session timeout if the request is
+// not using the current session.
+func (s *Server) record(e Endpoint, r *http.Request) ([]byte, Failure, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Endpoint: e,
+		Method:   r.Method,
+		Header:   r.Header.Clone(),
+		Query:    r.URL.Query(),
+		Body:     body,
+	})
+
+	if failures := s.failures[e]; len(failures) > 0 {
+		s.failures[e] = failures[1:]
+		return body, failures[0], nil
+	}
+
+	if e != EndpointAuth && r.Header.Get("AccessToken") != s.accessToken() {
+		return body, SessionTimeout, nil
+	}
+
+	return body, 0, nil
+}
+
+// serve writes the result of v as JSON unless the request has to fail. v is
+// called with the request body.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, e Endpoint, v func(body []byte) interface{}) {
+	body, failure, err := s.record(e, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch failure {
+	case SessionTimeout:
+		w.WriteHeader(440)
+		return
+	case ServerError:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	case MalformedJSON:
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"malformed": `)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v(body))
+}
+
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, EndpointAuth, func([]byte) interface{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		token := s.accessToken()
+		return map[string]interface{}{
+			"accessToken": token,
+			"token": map[string]string{
+				"accessToken":  token,
+				"refreshToken": "oebbtest-refresh-token",
+			},
+			"channel":        "inet",
+			"supportId":      "oebbtest-support-id",
+			"sessionId":      fmt.Sprintf("oebbtest-session-%d", s.session),
+			"sessionTimeout": 1800,
+		}
+	})
+}
+
+func (s *Server) handleStations(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, EndpointStations, func([]byte) interface{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return encodeStations(s.stations[strings.ToLower(r.URL.Query().Get("name"))])
+	})
+}
+
+func (s *Server) handleNearby(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, EndpointNearby, func([]byte) interface{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return encodeStations(s.nearby)
+	})
+}
+
+// apiStation is a station encoded using the API field names, Station itself
+// hides "meta".
+type apiStation struct {
+	Latitude  int    `json:"latitude"`
+	Longitude int    `json:"longitude"`
+	Name      string `json:"name,omitempty"`
+	Meta      string `json:"meta,omitempty"`
+	Number    int    `json:"number"`
+}
+
+func encodeStations(stations []client.Station) []apiStation {
+	encoded := []apiStation{}
+	for _, st := range stations {
+		out := apiStation{
+			Latitude:  st.Latitude,
+			Longitude: st.Longitude,
+			Name:      st.Name,
+			Number:    st.Number,
+		}
+		if st.Meta {
+			out.Name, out.Meta = "", st.Name
+		}
+		encoded = append(encoded, out)
+	}
+	return encoded
+}
+
+func (s *Server) handleTimetable(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, EndpointTimetable, func(body []byte) interface{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var tr TimetableRequest
+		json.Unmarshal(body, &tr)
+
+		var connections []client.Connection
+		if s.timetable != nil {
+			connections = s.timetable(tr)
+		} else {
+			connections = s.defaultTimetable(tr)
+		}
+
+		if connections == nil {
+			connections = []client.Connection{}
+		}
+		return map[string]interface{}{
+			"connections": connections,
+		}
+	})
+}
+
+func (s *Server) defaultTimetable(tr TimetableRequest) []client.Connection {
+	departure, err := tr.Departure()
+	if err != nil {
+		return nil
+	}
+
+	var connections []client.Connection
+	for _, c := range s.connections {
+		dep, err := client.ParseTime(c.From.Departure)
+		if err != nil || dep.Before(departure) {
+			continue
+		}
+		if tr.Filter["direct"] && c.Switches > 0 {
+			continue
+		}
+		connections = append(connections, c)
+	}
+
+	sort.SliceStable(connections, func(i, j int) bool {
+		return connections[i].From.Departure < connections[j].From.Departure
+	})
+
+	if len(connections) > tr.Count {
+		connections = connections[:tr.Count]
+	}
+
+	return connections
+}
+
+func (s *Server) handleDepartures(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, EndpointDepartures, func([]byte) interface{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		query := r.URL.Query()
+		station, _ := strconv.Atoi(query.Get("station"))
+		count, _ := strconv.Atoi(query.Get("count"))
+		departure, err := client.ParseTime(query.Get("datetimeDeparture"))
+
+		departures := []client.Departure{}
+		for _, d := range s.departures[station] {
+			dep, parseErr := client.ParseTime(d.Departure)
+			if err != nil || parseErr != nil || dep.Before(departure) {
+				continue
+			}
+			departures = append(departures, d)
+		}
+
+		sort.SliceStable(departures, func(i, j int) bool {
+			return departures[i].Departure < departures[j].Departure
+		})
+
+		if len(departures) > count {
+			departures = departures[:count]
+		}
+
+		return map[string]interface{}{
+			"departures": departures,
+		}
+	})
+}
+
+func (s *Server) handleJourneys(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, EndpointJourneys, func([]byte) interface{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		query := r.URL.Query()
+		key := strings.ToUpper(query.Get("category")) + " " + query.Get("number")
+
+		journeys := []client.Journey{}
+		for _, j := range s.journeys[key] {
+			if len(j.Stops) > 0 && strings.HasPrefix(j.Stops[0].Departure, query.Get("date")) {
+				journeys = append(journeys, j)
+			}
+		}
+
+		return map[string]interface{}{
+			"journeys": journeys,
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"errors"
+	"sync"
+)
+
+// Resolver looks up stations by name. It consults a StationIndex first and
+// only queries the API if the index has no fresh result for the name. Stations
+// returned by the API are added to the index, names without any are not.
+type Resolver struct {
+	Index *StationIndex
+	Auth  AuthInfo
+
+	// Client is used for API lookups. If nil, DefaultClient is used.
+	Client *Client
+
+	// Offline disables API lookups, stations are only searched for in the
+	// index.
+	Offline bool
+}
+
+// NewResolver returns a Resolver using the given index and authentication.
+func NewResolver(idx *StationIndex, a AuthInfo) *Resolver {
+	return &Resolver{
+		Index: idx,
+		Auth:  a,
+	}
+}
+
+// Resolve returns the stations matching name, best match first.
+//
+// If the API cannot be reached, Resolve falls back to a fuzzy search of the
+// index. Session timeouts are always returned to the caller, so it can
+// authenticate again and retry.
+func (r *Resolver) Resolve(name string) ([]Station, error) {
+	client := r.Client
+	if client == nil {
+		client = DefaultClient
+	}
+
+	if stations, ok := r.Index.Lookup(name); ok {
+		client.log("stations found in index", "name", name, "stations", len(stations))
+		return stations, nil
+	}
+
+	if r.Offline {
+		stations := r.Index.Search(name, 0)
+		client.log("searched index", "name", name, "stations", len(stations))
+		return stations, nil
+	}
+
+	stations, err := client.GetStations(name, r.Auth)
+	if err != nil {
+		var timeoutErr *SessionTimeoutError
+		if errors.As(err, &timeoutErr) {
+			return nil, err
+		}
+
+		if stations := r.Index.Search(name, 0); len(stations) > 0 {
+			client.log("station lookup failed, using index", "name", name, "error", err, "stations", len(stations))
+			return stations, nil
+		}
+		return nil, err
+	}
+
+	// a typo or a temporarily empty response must not be remembered
+	if len(stations) > 0 {
+		r.Index.Add(name, stations...)
+	}
+	return stations, nil
+}
+
+// ResolveAll resolves several names at the same time. The result contains the
+// stations matching each name, in the order of names. If any lookup fails,
+// the first error in that order is returned.
+func (r *Resolver) ResolveAll(names ...string) ([][]Station, error) {
+	results := make([][]Station, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i], errs[i] = r.Resolve(name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,73 @@
+package client
+
+import "strings"
+
+// SectionKind is the means of travel of a section.
+type SectionKind string
+
+// The kinds of sections.
+const (
+	// KindJourney is a ride on a train or tram.
+	KindJourney SectionKind = "journey"
+	// KindBus is a ride on a bus, including rail replacement buses.
+	KindBus SectionKind = "bus"
+	// KindOnDemand is a ride on a taxi or another on-demand service, which
+	// may have to be booked in advance.
+	KindOnDemand SectionKind = "on_demand"
+	// KindWalk is a walk, e.g. to a different station. Its length is in
+	// Section.Distance.
+	KindWalk SectionKind = "walk"
+	// KindTransfer is a change within a station.
+	KindTransfer SectionKind = "transfer"
+)
+
+// sectionTypes maps the section types of the API to their kinds. The API's
+// values have not been checked against a recorded response, so this lists
+// likely spellings, and Kind falls back on the category for unknown types.
+var sectionTypes = map[string]SectionKind{
+	"journey":   KindJourney,
+	"jny":       KindJourney,
+	"bus":       KindBus,
+	"taxi":      KindOnDemand,
+	"kiss":      KindOnDemand,
+	"on_demand": KindOnDemand,
+	"ondemand":  KindOnDemand,
+	"walk":      KindWalk,
+	"footpath":  KindWalk,
+	"transfer":  KindTransfer,
+	"trsf":      KindTransfer,
+}
+
+// Vehicle reports whether the passenger rides a vehicle in sections of kind
+// k, as opposed to walking or changing.
+func (k SectionKind) Vehicle() bool {
+	return k == KindJourney || k == KindBus || k == KindOnDemand
+}
+
+// Kind returns the kind of the section. Sections of an unknown type are
+// walks if they have no category and journeys or bus rides otherwise.
+func (s Section) Kind() SectionKind {
+	kind, ok := sectionTypes[strings.ToLower(s.Type)]
+	switch {
+	case !ok && s.Category.Number == "" && s.Category.Name == "":
+		return KindWalk
+	case !ok || kind == KindJourney:
+		if isBus(s.Category) {
+			return KindBus
+		}
+		return KindJourney
+	}
+	return kind
+}
+
+// isBus reports whether c is a bus category, e.g. "Bus" or "SEV" for rail
+// replacement buses.
+func isBus(c Category) bool {
+	for _, name := range []string{c.Name, c.ShortName, c.DisplayName} {
+		switch strings.ToLower(name) {
+		case "bus", "sev", "ev", "icb", "postbus":
+			return true
+		}
+	}
+	return false
+}
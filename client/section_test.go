@@ -0,0 +1,61 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+func TestSectionKind(t *testing.T) {
+	rj := client.Category{DisplayName: "RJ", Number: "553"}
+	bus := client.Category{Name: "Bus", ShortName: "Bus", Number: "200"}
+
+	tests := []struct {
+		typ      string
+		category client.Category
+		want     client.SectionKind
+	}{
+		{"journey", rj, client.KindJourney},
+		{"JNY", rj, client.KindJourney},
+		{"journey", bus, client.KindBus},
+		{"", bus, client.KindBus},
+		{"", rj, client.KindJourney},
+		{"", client.Category{}, client.KindWalk},
+		{"walk", client.Category{}, client.KindWalk},
+		{"transfer", client.Category{}, client.KindTransfer},
+		{"taxi", client.Category{Name: "AST"}, client.KindOnDemand},
+		{"unknown", rj, client.KindJourney},
+	}
+
+	for _, tt := range tests {
+		s := client.Section{Type: tt.typ, Category: tt.category}
+		if got := s.Kind(); got != tt.want {
+			t.Errorf("kind of %q section with category %q: got %q, want %q", tt.typ, tt.category.Name+tt.category.DisplayName, got, tt.want)
+		}
+	}
+}
+
+func TestTransfersSkipWalks(t *testing.T) {
+	conn := withTransfer("c0", 0, 15)
+	walk := client.Section{
+		From:     client.DepartureStation{Name: "Bruck/Mur", Departure: oebbtest.At(102)},
+		To:       client.ArrivalStation{Name: "Bruck/Mur Bahnhofplatz", Arrival: oebbtest.At(108)},
+		Type:     "walk",
+		Distance: 350,
+	}
+	conn.Sections = []client.Section{conn.Sections[0], walk, conn.Sections[1]}
+	conn.Sections[2].Type = "bus"
+	conn.Sections[2].From.Name = walk.To.Name
+
+	transfers, err := conn.Transfers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 {
+		t.Fatalf("got %d transfers, want 1", len(transfers))
+	}
+	if tr := transfers[0]; tr.Station != "Bruck/Mur" || !tr.PlatformChange() {
+		t.Errorf("got transfer at %q (platform change %v), want a change of stations at Bruck/Mur", tr.Station, tr.PlatformChange())
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"errors"
+	"sync"
+)
+
+// Session shares an authentication between concurrent users of the API. It
+// authenticates on first use and again whenever the API reports that the
+// session expired.
+type Session struct {
+	// Client is used for all requests. If nil, DefaultClient is used.
+	Client *Client
+
+	mu   sync.Mutex
+	auth *AuthInfo
+}
+
+// NewSession returns a session which sends its requests using c.
+func NewSession(c *Client) *Session {
+	return &Session{Client: c}
+}
+
+func (s *Session) client() *Client {
+	if s.Client == nil {
+		return DefaultClient
+	}
+	return s.Client
+}
+
+// AuthInfo returns the current authentication, authenticating if there is
+// none yet.
+func (s *Session) AuthInfo() (AuthInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.auth != nil {
+		return *s.auth, nil
+	}
+
+	a, err := s.client().Auth()
+	if err != nil {
+		return AuthInfo{}, err
+	}
+	s.auth = &a
+
+	return a, nil
+}
+
+// renew authenticates again, unless another user of the session already did
+// so after expired was found to be expired.
+func (s *Session) renew(expired AuthInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.auth != nil && s.auth.AccessToken != expired.AccessToken {
+		return nil
+	}
+
+	s.client().log("session expired, authenticating again")
+	a, err := s.client().Auth()
+	if err != nil {
+		s.auth = nil
+		return err
+	}
+	s.auth = &a
+
+	return nil
+}
+
+// Do calls f with the current authentication. If f fails with a
+// SessionTimeoutError, Do authenticates again and retries f once.
+func (s *Session) Do(f func(c *Client, a AuthInfo) error) error {
+	a, err := s.AuthInfo()
+	if err != nil {
+		return err
+	}
+
+	err = f(s.client(), a)
+	var timeoutErr *SessionTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	if err := s.renew(a); err != nil {
+		return err
+	}
+
+	a, err = s.AuthInfo()
+	if err != nil {
+		return err
+	}
+
+	return f(s.client(), a)
+}
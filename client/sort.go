@@ -0,0 +1,91 @@
+package client
+
+import (
+	"sort"
+	"time"
+)
+
+// The sort types supported by the API.
+const (
+	SortDeparture = "DEPARTURE"
+	SortArrival   = "ARRIVAL"
+)
+
+// DefaultTransferPenalty is the penalty per transfer used by Best.
+const DefaultTransferPenalty = 10 * time.Minute
+
+// Less reports whether connection a ranks before connection b. There is no
+// order by price, as connections carry no prices.
+type Less func(a, b Connection) bool
+
+// SortConnections sorts connections by the first of less which ranks two
+// connections differently. Connections ranked equally by all of them keep
+// their order.
+func SortConnections(connections []Connection, less ...Less) {
+	sort.SliceStable(connections, func(i, j int) bool {
+		for _, l := range less {
+			switch {
+			case l(connections[i], connections[j]):
+				return true
+			case l(connections[j], connections[i]):
+				return false
+			}
+		}
+		return false
+	})
+}
+
+// ByDeparture ranks connections by their scheduled departure.
+func ByDeparture(a, b Connection) bool {
+	return a.From.Departure < b.From.Departure
+}
+
+// ByArrival ranks connections by their expected arrival, including delays.
+func ByArrival(a, b Connection) bool {
+	return arrival(a).Before(arrival(b))
+}
+
+// ByDuration ranks connections by their scheduled travel time.
+func ByDuration(a, b Connection) bool {
+	return a.Duration < b.Duration
+}
+
+// ByTransfers ranks connections by their number of transfers.
+func ByTransfers(a, b Connection) bool {
+	return numTransfers(a) < numTransfers(b)
+}
+
+// Best ranks connections by their expected arrival, adding transferPenalty for
+// every transfer. Cancelled connections rank last.
+func Best(transferPenalty time.Duration) Less {
+	score := func(c Connection) time.Time {
+		return arrival(c).Add(time.Duration(numTransfers(c)) * transferPenalty)
+	}
+	return func(a, b Connection) bool {
+		if a.Cancelled() != b.Cancelled() {
+			return b.Cancelled()
+		}
+		return score(a).Before(score(b))
+	}
+}
+
+// arrival returns the expected arrival of c, or the zero time if it is
+// invalid.
+func arrival(c Connection) time.Time {
+	t, _ := expected(c.To.Arrival, c.To.ArrivalDelay)
+	return t
+}
+
+// numTransfers returns the number of transfers between the trains of c.
+func numTransfers(c Connection) int {
+	n := 0
+	for _, s := range c.Sections {
+		if s.Kind().Vehicle() {
+			n++
+		}
+	}
+	if n == 0 {
+		return c.Switches
+	}
+	return n - 1
+}
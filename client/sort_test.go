@@ -0,0 +1,54 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+func TestSortConnections(t *testing.T) {
+	// c0 departs first but changes twice, c1 is direct but slow, c2 is
+	// fast with one change
+	c0 := withTransfer("c0", 0, 5)
+	c0.Sections = append(c0.Sections, c0.Sections[1])
+	c1 := connection("c1", 10)
+	c1.Duration = int(3 * time.Hour / time.Millisecond)
+	c1.To.Arrival = oebbtest.At(190)
+	c2 := withTransfer("c2", 20, 5)
+	c2.Duration = int(2 * time.Hour / time.Millisecond)
+	c2.To.Arrival = oebbtest.At(140)
+
+	tests := []struct {
+		name string
+		less []client.Less
+		want []string
+	}{
+		{"departure", []client.Less{client.ByDeparture}, []string{"c0", "c1", "c2"}},
+		{"arrival", []client.Less{client.ByArrival}, []string{"c2", "c0", "c1"}},
+		{"duration", []client.Less{client.ByDuration}, []string{"c2", "c0", "c1"}},
+		{"transfers", []client.Less{client.ByTransfers, client.ByDuration}, []string{"c1", "c2", "c0"}},
+		{"best", []client.Less{client.Best(client.DefaultTransferPenalty)}, []string{"c2", "c0", "c1"}},
+	}
+
+	for _, tt := range tests {
+		connections := []client.Connection{c0, c1, c2}
+		client.SortConnections(connections, tt.less...)
+		if got := ids(connections); len(got) != 3 || got[0] != tt.want[0] || got[1] != tt.want[1] || got[2] != tt.want[2] {
+			t.Errorf("sorted by %s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// trains skipping some stops may still serve the passenger's stops,
+	// only cancelled trains rank last
+	c2.Sections[0].PartiallyCancelled = true
+	connections := []client.Connection{c0, c1, c2}
+	client.SortConnections(connections, client.Best(client.DefaultTransferPenalty))
+	assertIDs(t, connections, "c2", "c0", "c1")
+
+	c2.Sections[0].Cancelled = true
+	connections = []client.Connection{c0, c1, c2}
+	client.SortConnections(connections, client.Best(client.DefaultTransferPenalty))
+	assertIDs(t, connections, "c0", "c1", "c2")
+}
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// StationIndex is a local index of stations returned by previous lookups. It
+// can be persisted to disk and supports fuzzy, diacritic-insensitive searches,
+// so stations can be found without querying the API.
+//
+// A StationIndex is safe for concurrent use.
+type StationIndex struct {
+	// TTL is the duration after which entries are considered stale and are
+	// ignored. A TTL of zero means entries never expire.
+	TTL time.Duration
+
+	mu       sync.Mutex
+	stations map[int]indexedStation
+	queries  map[string]indexedQuery
+}
+
+type indexedStation struct {
+	Latitude  int       `json:"latitude"`
+	Longitude int       `json:"longitude"`
+	Name      string    `json:"name"`
+	Meta      bool      `json:"meta"`
+	Number    int       `json:"number"`
+	Updated   time.Time `json:"updated"`
+}
+
+// indexedQuery records which stations the API returned for a query, in order.
+type indexedQuery struct {
+	Numbers []int     `json:"numbers"`
+	Updated time.Time `json:"updated"`
+}
+
+type stationIndexFile struct {
+	Stations []indexedStation        `json:"stations"`
+	Queries  map[string]indexedQuery `json:"queries"`
+}
+
+func (s indexedStation) station() Station {
+	return Station{
+		Latitude:  s.Latitude,
+		Longitude: s.Longitude,
+		Name:      s.Name,
+		Meta:      s.Meta,
+		Number:    s.Number,
+	}
+}
+
+// NewStationIndex returns an empty station index whose entries expire after
+// ttl.
+func NewStationIndex(ttl time.Duration) *StationIndex {
+	return &StationIndex{
+		TTL:      ttl,
+		stations: map[int]indexedStation{},
+		queries:  map[string]indexedQuery{},
+	}
+}
+
+// LoadStationIndex reads a station index previously written using Save. If
+// the file does not exist, an empty index is returned.
+func LoadStationIndex(path string, ttl time.Duration) (*StationIndex, error) {
+	idx := NewStationIndex(ttl)
+
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f stationIndexFile
+	if err := json.Unmarshal(bytes, &f); err != nil {
+		return nil, err
+	}
+
+	for _, s := range f.Stations {
+		idx.stations[s.Number] = s
+	}
+	for q, entry := range f.Queries {
+		idx.queries[q] = entry
+	}
+
+	return idx, nil
+}
+
+// Save writes the index to path. Expired entries are not written.
+func (idx *StationIndex) Save(path string) error {
+	idx.mu.Lock()
+	f := stationIndexFile{
+		Queries: map[string]indexedQuery{},
+	}
+	for _, s := range idx.stations {
+		if idx.fresh(s.Updated) {
+			f.Stations = append(f.Stations, s)
+		}
+	}
+	for q, entry := range idx.queries {
+		if idx.fresh(entry.Updated) {
+			f.Queries[q] = entry
+		}
+	}
+	idx.mu.Unlock()
+
+	sort.Slice(f.Stations, func(i, j int) bool { return f.Stations[i].Number < f.Stations[j].Number })
+
+	bytes, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, bytes, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (idx *StationIndex) fresh(updated time.Time) bool {
+	return idx.TTL == 0 || time.Since(updated) < idx.TTL
+}
+
+// Add records stations returned by the API for query. The query may be empty
+// if the stations were not the result of a lookup by name.
+func (idx *StationIndex) Add(query string, stations ...Station) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	now := time.Now()
+	numbers := make([]int, 0, len(stations))
+	for _, s := range stations {
+		idx.stations[s.Number] = indexedStation{
+			Latitude:  s.Latitude,
+			Longitude: s.Longitude,
+			Name:      s.Name,
+			Meta:      s.Meta,
+			Number:    s.Number,
+			Updated:   now,
+		}
+		numbers = append(numbers, s.Number)
+	}
+
+	if query = normalizeStationName(query); query != "" {
+		idx.queries[query] = indexedQuery{
+			Numbers: numbers,
+			Updated: now,
+		}
+	}
+}
+
+// Lookup returns the stations the API returned for an earlier query of the
+// same name, if that result has not expired yet.
+func (idx *StationIndex) Lookup(query string) ([]Station, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.queries[normalizeStationName(query)]
+	if !ok || !idx.fresh(entry.Updated) {
+		return nil, false
+	}
+
+	stations := make([]Station, 0, len(entry.Numbers))
+	for _, number := range entry.Numbers {
+		s, ok := idx.stations[number]
+		if !ok || !idx.fresh(s.Updated) {
+			return nil, false
+		}
+		stations = append(stations, s.station())
+	}
+
+	return stations, true
+}
+
+// Stations returns all stations in the index which have not expired, sorted
+// by name.
+func (idx *StationIndex) Stations() []Station {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var stations []Station
+	for _, s := range idx.stations {
+		if idx.fresh(s.Updated) {
+			stations = append(stations, s.station())
+		}
+	}
+
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Name < stations[j].Name })
+	return stations
+}
+
+// Search performs a fuzzy search for query over all stations in the index and
+// returns up to max matches, best match first. A max of zero or less returns
+// all matches.
+//
+// Matching ignores case and diacritics ("Muerzzuschlag" and "Murzzuschlag"
+// both match "Mürzzuschlag") and allows abbreviated words ("Wien Westbf"
+// matches "Wien Westbahnhof").
+func (idx *StationIndex) Search(query string, max int) []Station {
+	queryTokens := strings.Fields(normalizeStationName(query))
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	type match struct {
+		station Station
+		score   int
+	}
+
+	var matches []match
+	for _, s := range idx.Stations() {
+		if score := matchStationName(queryTokens, strings.Fields(normalizeStationName(s.Name))); score > 0 {
+			matches = append(matches, match{s, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return len(matches[i].station.Name) < len(matches[j].station.Name)
+	})
+
+	if max > 0 && len(matches) > max {
+		matches = matches[:max]
+	}
+
+	stations := make([]Station, len(matches))
+	for i, m := range matches {
+		stations[i] = m.station
+	}
+	return stations
+}
+
+// matchStationName scores how well the query tokens match the name tokens.
+// Every query token has to match a name token, either exactly, as a prefix or
+// as a subsequence. A score of zero means no match.
+func matchStationName(query, name []string) int {
+	score := 0
+	for _, q := range query {
+		best := 0
+		for _, n := range name {
+			switch {
+			case q == n:
+				best = 3
+			case strings.HasPrefix(n, q) && best < 2:
+				best = 2
+			case isSubsequence(q, n) && best < 1:
+				best = 1
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+	return score
+}
+
+// isSubsequence reports whether all characters of sub appear in str in the
+// same order.
+func isSubsequence(sub, str string) bool {
+	runes := []rune(sub)
+	i := 0
+	for _, c := range str {
+		if i < len(runes) && runes[i] == c {
+			i++
+		}
+	}
+	return i == len(runes)
+}
+
+var stationNameReplacer = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"á", "a", "à", "a", "â", "a", "ã", "a", "å", "a", "ą", "a",
+	"ç", "c", "č", "c", "ć", "c",
+	"ď", "d", "đ", "d",
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "ě", "e", "ę", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ł", "l", "ľ", "l", "ĺ", "l",
+	"ñ", "n", "ň", "n", "ń", "n",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ő", "o",
+	"ř", "r", "ŕ", "r",
+	"š", "s", "ś", "s",
+	"ť", "t",
+	"ú", "u", "ù", "u", "û", "u", "ů", "u", "ű", "u",
+	"ý", "y",
+	"ž", "z", "ź", "z", "ż", "z",
+)
+
+// stationAbbreviations are expanded when normalizing, so that abbreviated and
+// written out station names match each other.
+var stationAbbreviations = map[string]string{
+	"hbf": "hauptbahnhof",
+	"bhf": "bahnhof",
+	"bf":  "bahnhof",
+}
+
+// normalizeStationName lowercases name, transliterates diacritics to plain
+// ASCII, replaces punctuation with spaces and expands common abbreviations.
+func normalizeStationName(name string) string {
+	name = stationNameReplacer.Replace(strings.ToLower(name))
+
+	tokens := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, t := range tokens {
+		if expanded, ok := stationAbbreviations[t]; ok {
+			tokens[i] = expanded
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
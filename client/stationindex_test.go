@@ -0,0 +1,191 @@
+package client_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+var (
+	muerzzuschlag = client.Station{Name: "Mürzzuschlag", Number: 8100034}
+	westbahnhof   = client.Station{Name: "Wien Westbahnhof", Number: 1291501}
+	wienMitte     = client.Station{Name: "Wien Mitte-Landstraße", Number: 1290201}
+	wienMeidling  = client.Station{Name: "Wien Meidling", Number: 1191201}
+	westendorf    = client.Station{Name: "Westendorf", Number: 8101191}
+)
+
+func newIndex() *client.StationIndex {
+	idx := client.NewStationIndex(0)
+	idx.Add("", wien, graz, muerzzuschlag, westbahnhof, wienMitte, wienMeidling, westendorf)
+	return idx
+}
+
+func names(stations []client.Station) []string {
+	var names []string
+	for _, s := range stations {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestStationIndexSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		max   int
+		want  []string
+	}{
+		{"Muerzzuschlag", 0, []string{"Mürzzuschlag"}},
+		{"Murzzuschlag", 0, []string{"Mürzzuschlag"}},
+		{"MÜRZZUSCHLAG", 0, []string{"Mürzzuschlag"}},
+		{"Wien Westbf", 0, []string{"Wien Westbahnhof"}},
+		{"Graz Hauptbahnhof", 0, []string{"Graz Hbf"}},
+		// exact words rank before prefixes, which rank before subsequences
+		{"Wien Mitte", 0, []string{"Wien Mitte-Landstraße"}},
+		{"Wien", 0, []string{"Wien Hbf", "Wien Meidling", "Wien Westbahnhof", "Wien Mitte-Landstraße"}},
+		{"West", 0, []string{"Westendorf", "Wien Westbahnhof"}},
+		{"Wstdf", 0, []string{"Westendorf"}},
+		{"Wien", 2, []string{"Wien Hbf", "Wien Meidling"}},
+		{"Linz", 0, nil},
+		{"", 0, nil},
+	}
+
+	idx := newIndex()
+	for _, tt := range tests {
+		got := names(idx.Search(tt.query, tt.max))
+		if len(got) != len(tt.want) {
+			t.Errorf("Search(%q, %d) = %q, want %q", tt.query, tt.max, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Search(%q, %d) = %q, want %q", tt.query, tt.max, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestStationIndexLookup(t *testing.T) {
+	idx := client.NewStationIndex(time.Hour)
+	idx.Add("Wien", wien, westbahnhof)
+
+	// queries are normalized like station names
+	stations, ok := idx.Lookup("  WIEN ")
+	if !ok || len(stations) != 2 || stations[0].Number != wien.Number || stations[1].Number != westbahnhof.Number {
+		t.Errorf("Lookup() = %+v, %v, want the stations in the order they were added", stations, ok)
+	}
+
+	if _, ok := idx.Lookup("Graz"); ok {
+		t.Error("Lookup() found a query which was never added")
+	}
+}
+
+func TestStationIndexExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stations.json")
+	file := `{
+		"stations": [{"name": "Wien Hbf", "number": 1290401, "updated": "2020-05-04T08:00:00Z"}],
+		"queries": {"wien": {"numbers": [1290401], "updated": "2020-05-04T08:00:00Z"}}
+	}`
+	if err := ioutil.WriteFile(path, []byte(file), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := client.LoadStationIndex(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadStationIndex() failed: %v", err)
+	}
+	if _, ok := idx.Lookup("Wien"); ok {
+		t.Error("Lookup() returned an expired query")
+	}
+	if stations := idx.Stations(); len(stations) != 0 {
+		t.Errorf("Stations() = %+v, want no expired stations", stations)
+	}
+
+	// a TTL of zero never expires
+	idx.TTL = 0
+	if stations, ok := idx.Lookup("Wien"); !ok || len(stations) != 1 {
+		t.Errorf("Lookup() = %+v, %v without a TTL, want Wien Hbf", stations, ok)
+	}
+}
+
+func TestStationIndexSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stations.json")
+
+	idx := client.NewStationIndex(time.Hour)
+	idx.Add("wien", wien, wienMeidling)
+	idx.Add("", muerzzuschlag)
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := client.LoadStationIndex(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadStationIndex() failed: %v", err)
+	}
+	if got := names(loaded.Stations()); len(got) != 3 || got[0] != "Mürzzuschlag" || got[1] != "Wien Hbf" || got[2] != "Wien Meidling" {
+		t.Errorf("loaded stations %q, want the saved ones", got)
+	}
+	if stations, ok := loaded.Lookup("Wien"); !ok || len(stations) != 2 || stations[0] != wien {
+		t.Errorf("loaded Lookup() = %+v, %v, want the saved query", stations, ok)
+	}
+
+	missing, err := client.LoadStationIndex(filepath.Join(t.TempDir(), "missing.json"), time.Hour)
+	if err != nil || len(missing.Stations()) != 0 {
+		t.Errorf("LoadStationIndex() of a missing file = %+v, %v, want an empty index", missing, err)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	srv, c, auth := newServer(t)
+	srv.AddStations("wien", wien)
+
+	r := client.NewResolver(client.NewStationIndex(time.Hour), auth)
+	r.Client = c
+
+	// the first lookup queries the API, the second one uses the index
+	for i := 0; i < 2; i++ {
+		stations, err := r.Resolve("Wien")
+		if err != nil || len(stations) != 1 || stations[0].Number != wien.Number {
+			t.Fatalf("Resolve() = %+v, %v, want Wien Hbf", stations, err)
+		}
+	}
+	if n := len(srv.Requests(oebbtest.EndpointStations)); n != 1 {
+		t.Errorf("sent %d station requests, want 1", n)
+	}
+
+	// names without any station are looked up again
+	for i := 0; i < 2; i++ {
+		if stations, err := r.Resolve("Wein"); err != nil || len(stations) != 0 {
+			t.Fatalf("Resolve() = %+v, %v, want no stations", stations, err)
+		}
+	}
+	if n := len(srv.Requests(oebbtest.EndpointStations)); n != 3 {
+		t.Errorf("sent %d station requests for a name without stations, want 2", n-1)
+	}
+	if _, ok := r.Index.Lookup("Wein"); ok {
+		t.Error("a name without stations was added to the index")
+	}
+
+	// offline, unknown queries are searched for in the index
+	r.Offline = true
+	if stations, err := r.Resolve("Wien Hauptbahnhof"); err != nil || len(stations) != 1 || stations[0].Number != wien.Number {
+		t.Errorf("offline Resolve() = %+v, %v, want Wien Hbf from the index", stations, err)
+	}
+	if n := len(srv.Requests(oebbtest.EndpointStations)); n != 3 {
+		t.Errorf("sent %d station requests offline, want none", n-3)
+	}
+
+	// online, failed lookups fall back to the index
+	r.Offline = false
+	srv.Fail(oebbtest.EndpointStations, oebbtest.ServerError, oebbtest.ServerError)
+	if stations, err := r.Resolve("Wien Hbf"); err != nil || len(stations) != 1 || stations[0].Number != wien.Number {
+		t.Errorf("Resolve() with a failing API = %+v, %v, want Wien Hbf from the index", stations, err)
+	}
+	if _, err := r.Resolve("Linz"); err == nil {
+		t.Error("Resolve() with a failing API and no match in the index succeeded")
+	}
+}
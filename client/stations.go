@@ -1,13 +1,11 @@
 package client
 
 import (
-	"bytes"
 	"encoding/json"
-	"net/http"
 	"net/url"
 )
 
-const stationsURL = "https://tickets.oebb.at/api/hafas/v1/stations"
+const stationsPath = "/api/hafas/v1/stations"
 
 type Station struct {
 	Latitude  int `json:"latitude"`
@@ -51,30 +49,22 @@ func (s *Station) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// GetStations looks up stations by name, best match first.
 func GetStations(name string, a AuthInfo) ([]Station, error) {
-	client := &http.Client{}
-
-	req, err := http.NewRequest("GET", stationsURL+"?name="+url.QueryEscape(name), nil)
-	req.Header.Add("Channel", a.Channel)
-	req.Header.Add("AccessToken", a.AccessToken)
-	req.Header.Add("SessionId", a.SessionID)
-	resp, err := client.Do(req)
+	return DefaultClient.GetStations(name, a)
+}
 
+// GetStations looks up stations by name, see the package-level GetStations.
+func (c *Client) GetStations(name string, a AuthInfo) ([]Station, error) {
+	req, err := c.newRequest("GET", stationsPath+"?name="+url.QueryEscape(name), nil, a)
 	if err != nil {
 		return nil, err
 	}
 
-	switch resp.StatusCode {
-	case 440:
-		// login time-out: session expird
-		return nil, &SessionTimeoutError{}
-	}
-
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(resp.Body)
-
 	var stations []Station
-	json.Unmarshal(buf.Bytes(), &stations)
+	if err := c.doCached(req, stationsCache, stationsCacheKey(name), &stations); err != nil {
+		return nil, err
+	}
 
 	return stations, nil
 }
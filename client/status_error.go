@@ -0,0 +1,13 @@
+package client
+
+import "fmt"
+
+// StatusError is returned when the API responds with an unexpected HTTP
+// status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API responded with unexpected status %d", e.StatusCode)
+}
@@ -0,0 +1,109 @@
+package client
+
+import "time"
+
+// Transfer is a change from one train to the next within a connection.
+type Transfer struct {
+	// Arrival is the section arriving at the transfer and Departure the
+	// section departing from it. Walks and changes between them are not
+	// included.
+	Arrival, Departure Section
+
+	// Station is the station the passenger changes at. If the departing
+	// train leaves from a different station, e.g. after a walk, it is the
+	// station the arriving train stops at.
+	Station string
+
+	// ArrivalPlatform and DeparturePlatform are the platforms of the trains,
+	// including platform changes.
+	ArrivalPlatform, DeparturePlatform string
+
+	// Scheduled is the scheduled time between the arrival and the
+	// departure. Expected includes the real-time delays of both trains, it
+	// is negative if the departing train is expected to leave before the
+	// arriving train arrives.
+	Scheduled, Expected time.Duration
+}
+
+// PlatformChange reports whether the trains use different platforms, or
+// whether the passenger has to walk to a different station.
+func (t Transfer) PlatformChange() bool {
+	if t.Arrival.To.Name != t.Departure.From.Name {
+		return true
+	}
+	return t.ArrivalPlatform != "" && t.DeparturePlatform != "" && t.ArrivalPlatform != t.DeparturePlatform
+}
+
+// Feasible reports whether the expected transfer time is at least min.
+func (t Transfer) Feasible(min time.Duration) bool {
+	return t.Expected >= min
+}
+
+// expected returns the expected time of a stop.
+func expected(scheduled, actual string) (time.Time, error) {
+	if actual != "" {
+		scheduled = actual
+	}
+	return ParseTime(scheduled)
+}
+
+func platform(scheduled, deviation string) string {
+	if deviation != "" {
+		return deviation
+	}
+	return scheduled
+}
+
+// Transfers returns the transfers between the trains of the connection, in
+// order.
+func (c Connection) Transfers() ([]Transfer, error) {
+	var (
+		transfers []Transfer
+		prev      *Section
+	)
+	for i := range c.Sections {
+		s := &c.Sections[i]
+		if !s.Kind().Vehicle() {
+			continue
+		}
+
+		if prev != nil {
+			t, err := newTransfer(*prev, *s)
+			if err != nil {
+				return nil, err
+			}
+			transfers = append(transfers, t)
+		}
+		prev = s
+	}
+	return transfers, nil
+}
+
+func newTransfer(arr, dep Section) (Transfer, error) {
+	scheduledArr, err := ParseTime(arr.To.Arrival)
+	if err != nil {
+		return Transfer{}, err
+	}
+	scheduledDep, err := ParseTime(dep.From.Departure)
+	if err != nil {
+		return Transfer{}, err
+	}
+	expectedArr, err := expected(arr.To.Arrival, arr.To.ArrivalDelay)
+	if err != nil {
+		return Transfer{}, err
+	}
+	expectedDep, err := expected(dep.From.Departure, dep.From.DepartureDelay)
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	return Transfer{
+		Arrival:           arr,
+		Departure:         dep,
+		Station:           arr.To.Name,
+		ArrivalPlatform:   platform(arr.To.ArrivalPlatform, arr.To.ArrivalPlatformDeviation),
+		DeparturePlatform: platform(dep.From.DeparturePlatform, dep.From.DeparturePlatformDeviation),
+		Scheduled:         scheduledDep.Sub(scheduledArr),
+		Expected:          expectedDep.Sub(expectedArr),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+func TestTransfers(t *testing.T) {
+	conn := client.Connection{Sections: []client.Section{
+		{
+			From:     client.DepartureStation{Name: wien.Name, Departure: oebbtest.At(0)},
+			To:       client.ArrivalStation{Name: "Bruck/Mur", Arrival: oebbtest.At(100), ArrivalDelay: oebbtest.At(106), ArrivalPlatform: "3"},
+			Category: client.Category{DisplayName: "RJ", Number: "553"},
+		},
+		{
+			From:     client.DepartureStation{Name: "Bruck/Mur", Departure: oebbtest.At(110), DeparturePlatform: "3"},
+			To:       client.ArrivalStation{Name: "Leoben Hbf", Arrival: oebbtest.At(125)},
+			Category: client.Category{DisplayName: "S", Number: "1"},
+		},
+		// walk to the bus stop
+		{
+			From: client.DepartureStation{Name: "Leoben Hbf", Departure: oebbtest.At(125)},
+			To:   client.ArrivalStation{Name: "Leoben Bahnhof", Arrival: oebbtest.At(130)},
+		},
+		{
+			From:     client.DepartureStation{Name: "Leoben Bahnhof", Departure: oebbtest.At(130), DepartureDelay: oebbtest.At(131)},
+			To:       client.ArrivalStation{Name: "Eisenerz", Arrival: oebbtest.At(190)},
+			Category: client.Category{DisplayName: "Bus", Number: "270"},
+		},
+	}}
+
+	transfers, err := conn.Transfers()
+	if err != nil {
+		t.Fatalf("Transfers() failed: %v", err)
+	}
+	if len(transfers) != 2 {
+		t.Fatalf("got %d transfers, want 2", len(transfers))
+	}
+
+	tr := transfers[0]
+	if tr.Station != "Bruck/Mur" || tr.Scheduled != 10*time.Minute || tr.Expected != 4*time.Minute {
+		t.Errorf("got transfer at %s, scheduled %v, expected %v", tr.Station, tr.Scheduled, tr.Expected)
+	}
+	if tr.PlatformChange() {
+		t.Error("got platform change for transfer on the same platform")
+	}
+	if tr.Feasible(5*time.Minute) || !tr.Feasible(4*time.Minute) {
+		t.Error("got wrong feasibility for a 4 minute transfer")
+	}
+
+	tr = transfers[1]
+	if tr.Departure.Category.Number != "270" || tr.Scheduled != 5*time.Minute || tr.Expected != 6*time.Minute {
+		t.Errorf("got transfer to %s, scheduled %v, expected %v", tr.Departure.Category.Number, tr.Scheduled, tr.Expected)
+	}
+	if !tr.PlatformChange() {
+		t.Error("got no platform change for transfer to a different station")
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage station aliases",
+	Long: `Manage station aliases.
+
+An alias can be used anywhere a station name is accepted. The station an alias
+refers to is looked up once when the alias is added and stays fixed after
+that.`,
+}
+
+var aliasAddCmd = &cobra.Command{
+	Use:   "add [alias] [station]",
+	Short: "Add or replace a station alias",
+	Args:  usageArgs(cobra.ExactArgs(2)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		resolver, err := newResolver()
+		if err != nil {
+			return err
+		}
+
+		station, err := lookupStation(cfg, args[1], resolver)
+		if err != nil {
+			return err
+		}
+		saveStationIndex(resolver.Index)
+
+		cfg.Aliases[args[0]] = station
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s -> %s\n", bold(args[0]), station.Name)
+
+		return nil
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List station aliases",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(cfg.Aliases))
+		for name := range cfg.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Printf("%s -> %s\n", bold(name), cfg.Aliases[name].Name)
+		}
+
+		return nil
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:   "remove [alias]",
+	Short: "Remove a station alias",
+	Args:  usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := cfg.Aliases[args[0]]; !ok {
+			return usageError("no such alias: %s", args[0])
+		}
+
+		delete(cfg.Aliases, args[0])
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
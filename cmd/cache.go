@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/spf13/cobra"
+)
+
+// responseCacheDir is the directory of the response cache enabled by --cache,
+// relative to the XDG cache directory.
+const responseCacheDir = "oebb-cli/responses"
+
+func newResponseCacheStore() *oebb.DiskStore {
+	return oebb.NewDiskStore(filepath.Join(xdg.CacheHome, responseCacheDir))
+}
+
+// setupCache configures the response cache of the API client according to
+// --cache and --refresh.
+func setupCache(cmd *cobra.Command) error {
+	enabled, err := cmd.Flags().GetBool("cache")
+	if err != nil {
+		return err
+	}
+
+	refresh, err := cmd.Flags().GetBool("refresh")
+	if err != nil {
+		return err
+	}
+
+	if refresh && !enabled {
+		return usageError("--refresh requires --cache")
+	}
+
+	if enabled {
+		apiClient.Cache = oebb.NewCache(newResponseCacheStore())
+		apiClient.BypassCache = refresh
+	}
+
+	return nil
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the response cache",
+	Long: `Manage the response cache.
+
+With --cache, station lookups are cached for a day and timetables for a
+minute. --refresh ignores cached responses, but updates the cache.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached responses",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := newResponseCacheStore().Clear(); err != nil {
+			return err
+		}
+
+		fmt.Println("Cleared the response cache")
+		return nil
+	},
+}
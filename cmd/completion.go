@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a shell completion script.
+
+To load completions in the current shell session:
+
+  bash: source <(oebb-cli completion bash)
+  zsh:  source <(oebb-cli completion zsh)
+  fish: oebb-cli completion fish | source
+
+Station names are completed from aliases, recent searches and stations found
+by previous searches. Completing never queries the ÖBB API.`,
+	Args:      usageArgs(cobra.ExactValidArgs(1)),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			err = rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = rootCmd.GenFishCompletion(os.Stdout, true)
+		}
+		if err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+// stationSuggestions returns completion candidates for a station argument.
+// Only locally available information is used, so this is fast enough to be
+// called on every tab press.
+func stationSuggestions(toComplete string) []string {
+	var suggestions []string
+	seen := map[string]bool{}
+
+	add := func(name, description string) {
+		if seen[name] || !strings.HasPrefix(strings.ToLower(name), strings.ToLower(toComplete)) {
+			return
+		}
+		seen[name] = true
+		if description != "" {
+			name += "\t" + description
+		}
+		suggestions = append(suggestions, name)
+	}
+
+	if cfg, err := loadConfig(); err == nil {
+		aliases := make([]string, 0, len(cfg.Aliases))
+		for alias := range cfg.Aliases {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+
+		for _, alias := range aliases {
+			add(alias, cfg.Aliases[alias].Name)
+		}
+	}
+
+	stations, _ := historyStations()
+	for _, station := range stations {
+		add(station.Name, "recent")
+	}
+
+	for _, station := range loadStationIndex().Stations() {
+		add(station.Name, "")
+	}
+
+	return suggestions
+}
+
+// completeStations completes the arguments at positions first to last
+// (inclusive) of a command as stations.
+func completeStations(first, last int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) < first || len(args) > last {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return stationSuggestions(toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completeAliases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var aliases []string
+	for alias, station := range cfg.Aliases {
+		aliases = append(aliases, alias+"\t"+station.Name)
+	}
+	sort.Strings(aliases)
+
+	return aliases, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeRoutes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var routes []string
+	for name, r := range cfg.Routes {
+		routes = append(routes, name+"\t"+r.String())
+	}
+	sort.Strings(routes)
+
+	return routes, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSortOrders completes the values of --sort.
+func completeSortOrders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var orders []string
+	for order := range sortOrders {
+		orders = append(orders, order)
+	}
+	sort.Strings(orders)
+
+	return orders, cobra.ShellCompDirectiveNoFileComp
+}
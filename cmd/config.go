@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/adrg/xdg"
+	oebb "github.com/chrboe/oebb/client"
+)
+
+const configFile = "oebb-cli/config.json"
+
+// route is a saved search between two stations. The stations are stored as
+// returned by the API, so the route keeps pointing to the same station
+// numbers even if a later lookup by name would return something else.
+type route struct {
+	From oebb.Station `json:"from"`
+	To   oebb.Station `json:"to"`
+	searchOptions
+}
+
+// searchOptions are the connection options of a saved route or a search in
+// the history. They are stored separately from oebb.ConnectionOptions, so
+// that new options of the client do not change the format of the files.
+type searchOptions struct {
+	Direct        bool          `json:"direct,omitempty"`
+	MinTransfer   time.Duration `json:"minTransfer,omitempty"`
+	MaxTransfers  int           `json:"maxTransfers,omitempty"`
+	MaxDuration   time.Duration `json:"maxDuration,omitempty"`
+	Only          []string      `json:"only,omitempty"`
+	Exclude       []string      `json:"exclude,omitempty"`
+	ShowCancelled bool          `json:"showCancelled,omitempty"`
+	MaxOccupancy  string        `json:"maxOccupancy,omitempty"`
+	FirstClass    bool          `json:"firstClass,omitempty"`
+	Wheelchair    bool          `json:"wheelchair,omitempty"`
+	AssistanceDog bool          `json:"assistanceDog,omitempty"`
+}
+
+func newSearchOptions(opts oebb.ConnectionOptions) searchOptions {
+	o := searchOptions{
+		Direct:        opts.Direct,
+		MinTransfer:   opts.MinTransfer,
+		MaxTransfers:  opts.MaxTransfers,
+		MaxDuration:   opts.MaxDuration,
+		Only:          opts.Only,
+		Exclude:       opts.Exclude,
+		ShowCancelled: opts.IncludeCancelled,
+		FirstClass:    opts.FirstClass,
+		Wheelchair:    opts.Accessibility.Wheelchair,
+		AssistanceDog: opts.Accessibility.AssistanceDog,
+	}
+	if opts.MaxOccupancy != oebb.OccupancyUnknown {
+		o.MaxOccupancy = opts.MaxOccupancy.String()
+	}
+	return o
+}
+
+func (o searchOptions) options() oebb.ConnectionOptions {
+	// the occupancy is stored by name, so invalid names mean no limit
+	maxOccupancy, _ := oebb.ParseOccupancy(o.MaxOccupancy)
+
+	return oebb.ConnectionOptions{
+		Direct:           o.Direct,
+		MinTransfer:      o.MinTransfer,
+		MaxTransfers:     o.MaxTransfers,
+		MaxDuration:      o.MaxDuration,
+		Only:             o.Only,
+		Exclude:          o.Exclude,
+		IncludeCancelled: o.ShowCancelled,
+		MaxOccupancy:     maxOccupancy,
+		FirstClass:       o.FirstClass,
+		Accessibility: oebb.Accessibility{
+			Wheelchair:    o.Wheelchair,
+			AssistanceDog: o.AssistanceDog,
+		},
+	}
+}
+
+// config is the persistent configuration of the CLI.
+type config struct {
+	Aliases map[string]oebb.Station `json:"aliases,omitempty"`
+	Routes  map[string]route        `json:"routes,omitempty"`
+}
+
+// loadConfig reads the config file. A missing config file is not an error,
+// an empty config is returned instead.
+func loadConfig() (*config, error) {
+	cfg := &config{
+		Aliases: map[string]oebb.Station{},
+		Routes:  map[string]route{},
+	}
+
+	path, err := xdg.SearchConfigFile(configFile)
+	if err != nil {
+		return cfg, nil
+	}
+
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bytes, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Aliases == nil {
+		cfg.Aliases = map[string]oebb.Station{}
+	}
+	if cfg.Routes == nil {
+		cfg.Routes = map[string]route{}
+	}
+
+	return cfg, nil
+}
+
+func saveConfig(cfg *config) error {
+	bytes, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path, err := xdg.ConfigFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, bytes, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the CLI.
+const (
+	exitOK              = 0
+	exitError           = 1 // unexpected errors
+	exitUsage           = 2 // invalid command line
+	exitStationNotFound = 3 // a station name did not match any station
+	exitNoConnections   = 4 // the search did not find any connections or trains
+	exitAPI             = 5 // the API could not be reached or misbehaved
+	exitAuth            = 6 // authentication against the API failed
+)
+
+// exitCodesHelp documents the exit codes in the help of the root command.
+const exitCodesHelp = `Exit codes:
+  0  success
+  1  unexpected error
+  2  invalid command line
+  3  station not found
+  4  no connections or train found
+  5  network or API error
+  6  authentication failed`
+
+// cliError is an error with a message meant for users, an exit code and a
+// machine-readable kind for the JSON error envelope.
+type cliError struct {
+	code int
+	kind string
+	msg  string
+	err  error
+
+	// reported is set if the error was already presented to the user in a
+	// human-readable way, so it only needs to be printed as JSON.
+	reported bool
+}
+
+func (e *cliError) Error() string {
+	if e.err != nil {
+		return e.msg + ": " + e.err.Error()
+	}
+	return e.msg
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+func usageError(format string, args ...interface{}) error {
+	return &cliError{code: exitUsage, kind: "usage", msg: fmt.Sprintf(format, args...)}
+}
+
+func stationNotFoundError(name string) error {
+	return &cliError{code: exitStationNotFound, kind: "station_not_found", msg: fmt.Sprintf("no station found for %q", name)}
+}
+
+func noNearbyStationError(lat, lon float64, radius int) error {
+	return &cliError{code: exitStationNotFound, kind: "station_not_found", msg: fmt.Sprintf("no station found within %dm of %f,%f", radius, lat, lon)}
+}
+
+func noConnectionsError(from, to oebb.Station) error {
+	return &cliError{
+		code:     exitNoConnections,
+		kind:     "no_connections",
+		msg:      fmt.Sprintf("no connections found from %s to %s", from.Name, to.Name),
+		reported: true,
+	}
+}
+
+func trainNotFoundError(train string, date time.Time) error {
+	return &cliError{
+		code: exitNoConnections,
+		kind: "train_not_found",
+		msg:  fmt.Sprintf("train %s does not run on %s", train, date.Format("2006-01-02")),
+	}
+}
+
+func authError(err error) error {
+	return &cliError{code: exitAuth, kind: "auth", msg: "failed to authenticate", err: err}
+}
+
+// usageArgs marks errors of an argument validator as usage errors.
+func usageArgs(validator cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := validator(cmd, args); err != nil {
+			return usageError("%s", err)
+		}
+		return nil
+	}
+}
+
+// classifyError turns any error into a cliError, based on its cause.
+func classifyError(err error) *cliError {
+	var cliErr *cliError
+	if errors.As(err, &cliErr) {
+		return cliErr
+	}
+
+	var (
+		timeoutErr *oebb.SessionTimeoutError
+		statusErr  *oebb.StatusError
+		netErr     net.Error
+		syntaxErr  *json.SyntaxError
+		typeErr    *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &timeoutErr):
+		return &cliError{code: exitAuth, kind: "auth", msg: "session expired", err: err}
+	case errors.As(err, &statusErr), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return &cliError{code: exitAPI, kind: "api", msg: "unexpected response from the ÖBB API", err: err}
+	case errors.As(err, &netErr):
+		return &cliError{code: exitAPI, kind: "network", msg: "could not reach the ÖBB API", err: err}
+	}
+
+	return &cliError{code: exitError, kind: "error", msg: err.Error()}
+}
+
+// errorEnvelope is the JSON representation of an error, printed to stdout
+// when --json is given.
+type errorEnvelope struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Kind    string `json:"kind"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// reportError presents err to the user and returns the exit code to use.
+func reportError(err error, asJSON bool) int {
+	cliErr := classifyError(err)
+
+	if asJSON {
+		var envelope errorEnvelope
+		envelope.Error.Code = cliErr.code
+		envelope.Error.Kind = cliErr.kind
+		envelope.Error.Message = cliErr.Error()
+		json.NewEncoder(os.Stdout).Encode(envelope)
+		return cliErr.code
+	}
+
+	if cliErr.reported {
+		return cliErr.code
+	}
+
+	fmt.Fprintln(os.Stderr, "Error: "+cliErr.Error())
+	if cliErr.code == exitUsage {
+		fmt.Fprintln(os.Stderr, "Run 'oebb-cli --help' for usage.")
+	}
+
+	return cliErr.code
+}
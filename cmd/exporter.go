@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/exporter"
+	"github.com/spf13/cobra"
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Export route delays as Prometheus metrics",
+	Long: `Export route delays as Prometheus metrics.
+
+Saved routes and departure boards of stations are queried periodically. The
+delays, cancellations and platform changes of the upcoming trains are served
+at /metrics, labelled by route or station, train category and number, along
+with the latency and errors of the API requests.
+
+By default, all saved routes are exported.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, err := cmd.Flags().GetString("listen")
+		if err != nil {
+			return err
+		}
+
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		if interval <= 0 {
+			return usageError("--interval must be positive")
+		}
+
+		routeNames, err := cmd.Flags().GetStringSlice("route")
+		if err != nil {
+			return err
+		}
+
+		stationNames, err := cmd.Flags().GetStringSlice("station")
+		if err != nil {
+			return err
+		}
+
+		numResults, err := cmd.Flags().GetInt("results")
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		// only export all routes if nothing was selected at all
+		if len(routeNames) > 0 || len(stationNames) == 0 {
+			routeNames, err = selectRoutes(cfg, routeNames)
+			if err != nil {
+				return err
+			}
+		}
+
+		var routes []exporter.Route
+		for _, name := range routeNames {
+			r := cfg.Routes[name]
+			routes = append(routes, exporter.Route{Name: name, From: r.From, To: r.To, Options: r.options()})
+		}
+
+		var stations []oebb.Station
+		if len(stationNames) > 0 {
+			resolver, err := newResolver()
+			if err != nil {
+				return err
+			}
+
+			stations, err = lookupStations(cfg, resolver, stationNames...)
+			if err != nil {
+				return err
+			}
+			saveStationIndex(resolver.Index)
+		}
+
+		if len(routes) == 0 && len(stations) == 0 {
+			return usageError("nothing to export, add a route or use --station")
+		}
+
+		e := exporter.New(oebb.NewSession(apiClient))
+		e.Routes = routes
+		e.Stations = stations
+		e.Results = numResults
+		e.Logger = apiClient.Logger
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go e.Run(ctx, interval)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", e)
+		return listenAndServe(listen, mux)
+	},
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/spf13/cobra"
+)
+
+const (
+	historyFile = "oebb-cli/history.jsonl"
+	historyMax  = 1000 // number of searches to keep
+)
+
+// historyEntry is a search as stored in the history file.
+type historyEntry struct {
+	SearchedAt time.Time     `json:"searchedAt"`
+	From       oebb.Station  `json:"from"`
+	To         oebb.Station  `json:"to"`
+	Departure  time.Time     `json:"departure"`
+	Results    int           `json:"results"`
+	Options    searchOptions `json:"options"`
+}
+
+func (e historyEntry) String() string {
+	str := fmt.Sprintf("%s %s -> %s (departure %s, %d results)",
+		e.SearchedAt.Format("2006-01-02 15:04"),
+		e.From.Name,
+		e.To.Name,
+		e.Departure.Format("15:04"),
+		e.Results)
+	if desc := describeOptions(e.Options.options()); len(desc) > 0 {
+		str += " " + strings.Join(desc, ", ")
+	}
+	return str
+}
+
+// loadHistory reads the search history, oldest search first. A missing
+// history file is not an error.
+func loadHistory() ([]historyEntry, error) {
+	path, err := xdg.SearchDataFile(historyFile)
+	if err != nil {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var history []historyEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry historyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// skip corrupted lines instead of losing the whole history
+			continue
+		}
+		history = append(history, entry)
+	}
+
+	return history, scanner.Err()
+}
+
+// recordHistory appends a search to the history, dropping the oldest searches
+// if the history grows too long.
+func recordHistory(entry historyEntry) error {
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	history = append(history, entry)
+	if len(history) > historyMax {
+		history = history[len(history)-historyMax:]
+	}
+
+	path, err := xdg.DataFile(historyFile)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	for _, e := range history {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// historyStations returns the stations of the search history, most recently
+// searched first, along with the number of times each station was searched.
+func historyStations() ([]oebb.Station, map[int]int) {
+	history, _ := loadHistory()
+
+	var stations []oebb.Station
+	counts := map[int]int{}
+	for i := len(history) - 1; i >= 0; i-- {
+		for _, station := range []oebb.Station{history[i].From, history[i].To} {
+			if counts[station.Number] == 0 {
+				stations = append(stations, station)
+			}
+			counts[station.Number]++
+		}
+	}
+
+	return stations, counts
+}
+
+// historyEntryByNumber returns the nth most recent search, starting at 1.
+func historyEntryByNumber(str string) (historyEntry, error) {
+	n, err := strconv.Atoi(str)
+	if err != nil || n < 1 {
+		return historyEntry{}, usageError("invalid history entry: %s", str)
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		return historyEntry{}, err
+	}
+
+	if n > len(history) {
+		return historyEntry{}, usageError("no such history entry: %d", n)
+	}
+
+	return history[len(history)-n], nil
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List previous searches",
+	Long: `List previous searches, most recent first.
+
+The number in front of each search can be used with "history rerun".`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		numEntries, err := cmd.Flags().GetInt("results")
+		if err != nil {
+			return err
+		}
+
+		history, err := loadHistory()
+		if err != nil {
+			return err
+		}
+
+		for n := 1; n <= len(history) && n <= numEntries; n++ {
+			fmt.Printf("%4d  %s\n", n, history[len(history)-n])
+		}
+
+		return nil
+	},
+}
+
+var historyRerunCmd = &cobra.Command{
+	Use:   "rerun [n]",
+	Short: "Repeat a previous search",
+	Long: `Repeat the nth most recent search, as listed by "history".
+
+The search uses the same stations and options, but departs now unless a
+different time is given.`,
+	Args: usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		depTimeStr, err := cmd.Flags().GetString("time")
+		if err != nil {
+			return err
+		}
+
+		entry, err := historyEntryByNumber(args[0])
+		if err != nil {
+			return err
+		}
+
+		depTime, err := parseDepartureTime(depTimeStr)
+		if err != nil {
+			return err
+		}
+
+		s := newSpinner()
+		s.Start()
+
+		pAuth, err := maybeCachedAuth()
+		if err != nil {
+			s.Stop()
+			return err
+		}
+
+		return searchConnections(s, *pAuth, entry.From, entry.To, depTime, entry.Results, entry.Options.options())
+	},
+}
+
+var aliasSuggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest aliases for frequently searched stations",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		aliased := map[int]bool{}
+		for _, station := range cfg.Aliases {
+			aliased[station.Number] = true
+		}
+
+		stations, counts := historyStations()
+		sort.SliceStable(stations, func(i, j int) bool {
+			return counts[stations[i].Number] > counts[stations[j].Number]
+		})
+
+		for _, station := range stations {
+			// only suggest stations which were searched more than once
+			words := strings.Fields(station.Name)
+			if aliased[station.Number] || counts[station.Number] < 2 || len(words) == 0 {
+				continue
+			}
+
+			name := strings.ToLower(words[0])
+			fmt.Printf("%3dx %s\n     oebb-cli alias add %s %q\n", counts[station.Number], bold(station.Name), name, station.Name)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	oebb "github.com/chrboe/oebb/client"
+)
+
+var (
+	wien = oebb.Station{Name: "Wien Hbf", Number: 1290401}
+	graz = oebb.Station{Name: "Graz Hbf", Number: 8100173}
+)
+
+// withDataHome makes the history use a temporary data directory.
+func withDataHome(t *testing.T) string {
+	t.Helper()
+
+	dataHome, dataDirs := xdg.DataHome, xdg.DataDirs
+	t.Cleanup(func() { xdg.DataHome, xdg.DataDirs = dataHome, dataDirs })
+
+	// DataDirs includes DataHome and is the only list searched
+	xdg.DataHome = t.TempDir()
+	xdg.DataDirs = []string{xdg.DataHome}
+	return filepath.Join(xdg.DataHome, historyFile)
+}
+
+func entry(minutes int) historyEntry {
+	searchedAt := time.Date(2020, 5, 4, 7, 0, 0, 0, time.UTC).Add(time.Duration(minutes) * time.Minute)
+	return historyEntry{
+		SearchedAt: searchedAt,
+		From:       wien,
+		To:         graz,
+		Departure:  searchedAt.Add(time.Hour),
+		Results:    5,
+	}
+}
+
+func TestHistoryRoundTrip(t *testing.T) {
+	withDataHome(t)
+
+	e := entry(0)
+	e.Options = newSearchOptions(oebb.ConnectionOptions{
+		Direct:           true,
+		MinTransfer:      10 * time.Minute,
+		MaxDuration:      3 * time.Hour,
+		Only:             []string{"RJ", "ICE"},
+		Exclude:          []string{"Bus"},
+		IncludeCancelled: true,
+		MaxOccupancy:     oebb.OccupancyMedium,
+		FirstClass:       true,
+		Accessibility:    oebb.Accessibility{Wheelchair: true, AssistanceDog: true},
+		SortType:         oebb.SortArrival,
+	})
+	if err := recordHistory(e); err != nil {
+		t.Fatalf("recordHistory() failed: %v", err)
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatalf("loadHistory() failed: %v", err)
+	}
+	if len(history) != 1 || !reflect.DeepEqual(history[0], e) {
+		t.Fatalf("loaded history %+v, want %+v", history, e)
+	}
+
+	opts := history[0].Options.options()
+	if !opts.Direct || opts.MinTransfer != 10*time.Minute || opts.MaxOccupancy != oebb.OccupancyMedium || !opts.Accessibility.Wheelchair {
+		t.Errorf("got options %+v", opts)
+	}
+	// the sort order is given again when rerunning a search
+	if opts.SortType != "" {
+		t.Errorf("sort type %q was stored", opts.SortType)
+	}
+}
+
+func TestHistoryFormat(t *testing.T) {
+	path := withDataHome(t)
+
+	// an entry of an earlier version, which stored the client's options
+	// under their Go names, and one of the current format
+	lines := []string{
+		`{"searchedAt":"2020-05-04T07:00:00Z","from":{"name":"Wien Hbf","number":1290401},"to":{"name":"Graz Hbf","number":8100173},"departure":"2020-05-04T08:00:00Z","results":5,"options":{"Direct":true}}`,
+		`{"searchedAt":"2020-05-04T07:01:00Z","from":{"name":"Wien Hbf","number":1290401},"to":{"name":"Graz Hbf","number":8100173},"departure":"2020-05-04T08:00:00Z","results":5,"options":{"minTransfer":600000000000,"maxOccupancy":"medium","wheelchair":true}}`,
+		`not json`,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatalf("loadHistory() failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d entries, want 2 without the corrupted line", len(history))
+	}
+	if !history[0].Options.Direct {
+		t.Errorf("got options %+v of the earlier format, want direct", history[0].Options)
+	}
+	want := searchOptions{MinTransfer: 10 * time.Minute, MaxOccupancy: "medium", Wheelchair: true}
+	if !reflect.DeepEqual(history[1].Options, want) {
+		t.Errorf("got options %+v, want %+v", history[1].Options, want)
+	}
+
+	// options which are not set are left out
+	bytes, err := json.Marshal(history[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bytes), `"options":{"minTransfer":600000000000,"maxOccupancy":"medium","wheelchair":true}`) {
+		t.Errorf("encoded entry as %s", bytes)
+	}
+}
+
+func TestHistoryTrim(t *testing.T) {
+	path := withDataHome(t)
+
+	var lines []string
+	for i := 0; i < historyMax; i++ {
+		bytes, err := json.Marshal(entry(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(bytes))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := historyMax; i < historyMax+2; i++ {
+		if err := recordHistory(entry(i)); err != nil {
+			t.Fatalf("recordHistory() failed: %v", err)
+		}
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatalf("loadHistory() failed: %v", err)
+	}
+	if len(history) != historyMax {
+		t.Fatalf("got %d entries, want %d", len(history), historyMax)
+	}
+	if !history[0].SearchedAt.Equal(entry(2).SearchedAt) || !history[historyMax-1].SearchedAt.Equal(entry(historyMax+1).SearchedAt) {
+		t.Errorf("kept entries from %v to %v, want the most recent ones", history[0].SearchedAt, history[historyMax-1].SearchedAt)
+	}
+}
+
+func TestHistoryEntryString(t *testing.T) {
+	e := entry(0)
+	if want := "2020-05-04 07:00 Wien Hbf -> Graz Hbf (departure 08:00, 5 results)"; e.String() != want {
+		t.Errorf("got %q, want %q", e.String(), want)
+	}
+
+	e.Options = searchOptions{Direct: true, Wheelchair: true}
+	if want := "2020-05-04 07:00 Wien Hbf -> Graz Hbf (departure 08:00, 5 results) direct, wheelchair"; e.String() != want {
+		t.Errorf("got %q, want %q", e.String(), want)
+	}
+}
+
+func TestHistoryEntryByNumber(t *testing.T) {
+	withDataHome(t)
+
+	for i := 0; i < 3; i++ {
+		if err := recordHistory(entry(i)); err != nil {
+			t.Fatalf("recordHistory() failed: %v", err)
+		}
+	}
+
+	for n := 1; n <= 3; n++ {
+		e, err := historyEntryByNumber(fmt.Sprint(n))
+		if err != nil {
+			t.Fatalf("historyEntryByNumber(%d) failed: %v", n, err)
+		}
+		if want := entry(3 - n); !e.SearchedAt.Equal(want.SearchedAt) {
+			t.Errorf("entry %d was searched at %v, want %v", n, e.SearchedAt, want.SearchedAt)
+		}
+	}
+
+	for _, invalid := range []string{"0", "-1", "4", "last"} {
+		_, err := historyEntryByNumber(invalid)
+		if err == nil {
+			t.Errorf("historyEntryByNumber(%q) succeeded, want error", invalid)
+		} else if code := classifyError(err).code; code != exitUsage {
+			t.Errorf("historyEntryByNumber(%q) exits with code %d, want %d", invalid, code, exitUsage)
+		}
+	}
+}
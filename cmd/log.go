@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// verbose is set if diagnostic output was requested using -v or --debug.
+var verbose bool
+
+// writerLogger writes diagnostic events as single lines of key=value pairs.
+type writerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newStderrLogger() *writerLogger {
+	return &writerLogger{w: os.Stderr}
+}
+
+func (l *writerLogger) Log(msg string, keyvals ...interface{}) {
+	var b strings.Builder
+	b.WriteString(time.Now().Format("15:04:05.000"))
+	b.WriteString(" ")
+	b.WriteString(msg)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = "(missing)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fmt.Fprintf(&b, " %v=", keyvals[i])
+
+		str := fmt.Sprint(value)
+		if strings.ContainsAny(str, " \t\n\"") {
+			str = fmt.Sprintf("%q", str)
+		}
+		b.WriteString(str)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(l.w, b.String())
+}
+
+// logEvent passes an event to the logger of the API client, so events of the
+// CLI itself end up in the same place.
+func logEvent(msg string, keyvals ...interface{}) {
+	if apiClient.Logger != nil {
+		apiClient.Logger.Log(msg, keyvals...)
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/adrg/xdg"
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/monitor"
+	"github.com/spf13/cobra"
+)
+
+const monitorStateFile = "oebb-cli/monitor-state.json"
+
+// monitorNotifiers returns the notifiers selected using the flags of cmd. If
+// none are selected, events are printed to stdout.
+func monitorNotifiers(cmd *cobra.Command) ([]monitor.Notifier, error) {
+	notifySend, err := cmd.Flags().GetBool("notify-send")
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := cmd.Flags().GetStringSlice("webhook")
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, err := cmd.Flags().GetStringArray("exec")
+	if err != nil {
+		return nil, err
+	}
+
+	var notifiers []monitor.Notifier
+	if notifySend {
+		notifiers = append(notifiers, monitor.NotifySend{})
+	}
+	for _, url := range webhooks {
+		notifiers = append(notifiers, monitor.Webhook{URL: url})
+	}
+	for _, hook := range hooks {
+		notifiers = append(notifiers, monitor.Exec{Path: "/bin/sh", Args: []string{"-c", hook}})
+	}
+
+	if len(notifiers) == 0 {
+		notifiers = append(notifiers, monitor.Writer{W: os.Stdout})
+	}
+
+	return notifiers, nil
+}
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Watch saved routes and notify about delays",
+	Long: `Watch saved routes and notify about delays.
+
+The next connections of the saved routes are checked periodically for delays
+above a threshold, cancellations and platform changes. Every change is
+notified once, using notify-send, webhooks (JSON POST) or commands, which get
+the event as JSON on stdin and in OEBB_* environment variables. Without any of
+these, changes are printed. Delays are notified again when they grow by five
+minutes.
+
+The schedule restricts when routes are checked, e.g. "mon-fri 06:00-09:00".
+
+By default, all saved routes are watched.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routeNames, err := cmd.Flags().GetStringSlice("route")
+		if err != nil {
+			return err
+		}
+
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		if interval <= 0 {
+			return usageError("--interval must be positive")
+		}
+
+		scheduleStr, err := cmd.Flags().GetString("schedule")
+		if err != nil {
+			return err
+		}
+		schedule, err := monitor.ParseSchedule(scheduleStr)
+		if err != nil {
+			return usageError("%s", err)
+		}
+
+		numResults, err := cmd.Flags().GetInt("results")
+		if err != nil {
+			return err
+		}
+
+		threshold, err := cmd.Flags().GetDuration("threshold")
+		if err != nil {
+			return err
+		}
+
+		once, err := cmd.Flags().GetBool("once")
+		if err != nil {
+			return err
+		}
+
+		notifiers, err := monitorNotifiers(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		routeNames, err = selectRoutes(cfg, routeNames)
+		if err != nil {
+			return err
+		}
+		if len(routeNames) == 0 {
+			return usageError("no saved routes to watch, add one using \"route add\"")
+		}
+
+		var routes []monitor.Route
+		for _, name := range routeNames {
+			r := cfg.Routes[name]
+			routes = append(routes, monitor.Route{Name: name, From: r.From, To: r.To, Options: r.options()})
+		}
+
+		statePath, err := xdg.DataFile(monitorStateFile)
+		if err != nil {
+			return err
+		}
+		state, err := monitor.LoadState(statePath)
+		if err != nil {
+			return err
+		}
+
+		m := monitor.New(oebb.NewSession(apiClient))
+		m.Routes = routes
+		m.Results = numResults
+		m.Threshold = threshold
+		m.Notifiers = notifiers
+		m.State = state
+		m.Logger = apiClient.Logger
+		m.Record = recordDelays
+
+		if once {
+			_, err := m.Check()
+			if saveErr := state.Save(statePath); err == nil {
+				err = saveErr
+			}
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			c := make(chan os.Signal, 1)
+			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+			<-c
+			cancel()
+		}()
+
+		m.Run(ctx, interval, schedule, statePath)
+		return nil
+	},
+}
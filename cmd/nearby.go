@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aybabtme/rgbterm"
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/spf13/cobra"
+)
+
+// coordinateRadius is the radius in meters in which the nearest station to a
+// coordinate given instead of a station name is searched.
+const coordinateRadius = 2000
+
+// parseCoordinate parses a coordinate given as "lat,lon" in degrees.
+func parseCoordinate(str string) (float64, float64, bool) {
+	parts := strings.Split(str, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+
+	return lat, lon, true
+}
+
+func nearbyStations(lat, lon float64, radius, maxResults int, auth *oebb.AuthInfo) ([]oebb.Station, error) {
+	stations, err := apiClient.GetNearbyStations(lat, lon, radius, maxResults, *auth)
+	if err != nil && handleTimeoutError(err, auth) {
+		stations, err = apiClient.GetNearbyStations(lat, lon, radius, maxResults, *auth)
+	}
+	return stations, err
+}
+
+// nearestStation returns the station nearest to the given coordinate. If the
+// resolver is offline, only stations in the index are considered.
+func nearestStation(lat, lon float64, resolver *oebb.Resolver) (oebb.Station, error) {
+	var stations []oebb.Station
+	if resolver.Offline {
+		for _, station := range resolver.Index.Stations() {
+			if station.DistanceTo(lat, lon) > coordinateRadius {
+				continue
+			}
+			if len(stations) == 0 || station.DistanceTo(lat, lon) < stations[0].DistanceTo(lat, lon) {
+				stations = []oebb.Station{station}
+			}
+		}
+	} else {
+		var err error
+		stations, err = nearbyStations(lat, lon, coordinateRadius, 1, &resolver.Auth)
+		if err != nil {
+			return oebb.Station{}, err
+		}
+	}
+
+	if len(stations) < 1 {
+		return oebb.Station{}, noNearbyStationError(lat, lon, coordinateRadius)
+	}
+
+	resolver.Index.Add("", stations...)
+	return stations[0], nil
+}
+
+var nearbyCmd = &cobra.Command{
+	Use:   "nearby [latitude] [longitude]",
+	Short: "List stations near a coordinate",
+	Args:  usageArgs(cobra.ExactArgs(2)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		radius, err := cmd.Flags().GetInt("radius")
+		if err != nil {
+			return err
+		}
+
+		numResults, err := cmd.Flags().GetInt("results")
+		if err != nil {
+			return err
+		}
+
+		lat, lon, ok := parseCoordinate(args[0] + "," + args[1])
+		if !ok {
+			return usageError("invalid coordinate: %s %s", args[0], args[1])
+		}
+
+		resolver, err := newResolver()
+		if err != nil {
+			return err
+		}
+
+		stations, err := nearbyStations(lat, lon, radius, numResults, &resolver.Auth)
+		if err != nil {
+			return err
+		}
+
+		resolver.Index.Add("", stations...)
+		saveStationIndex(resolver.Index)
+
+		if len(stations) < 1 {
+			fmt.Printf("No stations found within %dm\n", radius)
+		}
+
+		for _, station := range stations {
+			dist := rgbterm.InterpretStr(fmt.Sprintf("{#555555}%5.0fm{}", station.DistanceTo(lat, lon)))
+			fmt.Printf("%s %s\n", dist, station.Name)
+		}
+
+		return nil
+	},
+}
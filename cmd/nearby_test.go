@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+
+	oebb "github.com/chrboe/oebb/client"
+)
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		str      string
+		lat, lon float64
+		ok       bool
+	}{
+		{"48.2,16.37", 48.2, 16.37, true},
+		{"48.2, 16.37", 48.2, 16.37, true},
+		{"-33.9,-70.6", -33.9, -70.6, true},
+		{"90,180", 90, 180, true},
+		{"91,16", 0, 0, false},
+		{"48,181", 0, 0, false},
+		{"48.2", 0, 0, false},
+		{"48.2,16.37,1", 0, 0, false},
+		{"Wien,Graz", 0, 0, false},
+		{"Wien Hbf", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		lat, lon, ok := parseCoordinate(tt.str)
+		if lat != tt.lat || lon != tt.lon || ok != tt.ok {
+			t.Errorf("parseCoordinate(%q) = %v, %v, %v, want %v, %v, %v", tt.str, lat, lon, ok, tt.lat, tt.lon, tt.ok)
+		}
+	}
+}
+
+func TestNearestStationNotFound(t *testing.T) {
+	idx := oebb.NewStationIndex(0)
+	idx.Add("", graz)
+	resolver := oebb.NewResolver(idx, oebb.AuthInfo{})
+	resolver.Offline = true
+
+	// scripts get the same exit code as for unknown station names
+	_, err := nearestStation(48.2, 16.37, resolver)
+	if err == nil {
+		t.Fatal("nearestStation() found a station far away")
+	}
+	if code := classifyError(err).code; code != exitStationNotFound {
+		t.Errorf("got exit code %d, want %d", code, exitStationNotFound)
+	}
+}
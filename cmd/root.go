@@ -1,27 +1,220 @@
 package cmd
 
 import (
-	"fmt"
+	"encoding/json"
+	"net/http"
 	"os"
+	"time"
 
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/delaylog"
+	"github.com/chrboe/oebb/exporter"
+	"github.com/chrboe/oebb/monitor"
 	"github.com/spf13/cobra"
 )
 
+// apiClient is used for all requests to the API. It is set up according to
+// the persistent flags before any command runs.
+var apiClient = &oebb.Client{}
+
+// jsonOutput is set if results and errors should be printed as JSON.
+var jsonOutput bool
+
+// printJSON prints v as indented JSON to stdout.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// setupClient configures apiClient according to the persistent flags.
+func setupClient(cmd *cobra.Command) error {
+	record, err := cmd.Flags().GetString("record")
+	if err != nil {
+		return err
+	}
+
+	replay, err := cmd.Flags().GetString("replay")
+	if err != nil {
+		return err
+	}
+
+	if record != "" && replay != "" {
+		return usageError("--record and --replay cannot be used together")
+	}
+
+	var transport http.RoundTripper
+	if record != "" {
+		transport = oebb.NewRecorder(record)
+	}
+	if replay != "" {
+		cassette, err := oebb.LoadCassette(replay)
+		if err != nil {
+			return err
+		}
+		transport = oebb.NewReplayer(cassette)
+	}
+
+	if transport != nil {
+		apiClient.HTTPClient = &http.Client{Transport: transport}
+	}
+
+	v, err := cmd.Flags().GetBool("verbose")
+	if err != nil {
+		return err
+	}
+
+	debug, err := cmd.Flags().GetBool("debug")
+	if err != nil {
+		return err
+	}
+
+	verbose = v || debug
+	if verbose {
+		apiClient.Logger = newStderrLogger()
+	}
+	apiClient.LogBodies = debug
+
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return err
+	}
+	if concurrency < 1 {
+		return usageError("--concurrency must be at least 1")
+	}
+	apiClient.Concurrency = concurrency
+
+	return setupCache(cmd)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "oebb-cli",
 	Short: "A command line client for the ÖBB Tickets API",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long:  "A command line client for the ÖBB Tickets API\n\n" + exitCodesHelp,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return usageError("unknown command %q for %q", args[0], cmd.CommandPath())
+		}
+		return nil
+	},
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return setupClient(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if apiClient.Cache != nil {
+			stats := apiClient.Cache.Stats()
+			logEvent("cache stats",
+				"station_hits", stats.Stations.Hits, "station_misses", stats.Stations.Misses,
+				"timetable_hits", stats.Timetables.Hits, "timetable_misses", stats.Timetables.Misses)
+		}
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.Help()
-		os.Exit(1)
+		return &cliError{code: exitUsage, kind: "usage", msg: "no command given", reported: true}
 	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 func Execute() {
+	rootCmd.PersistentFlags().String("record", "", "Record all API requests and responses to a cassette file")
+	rootCmd.PersistentFlags().String("replay", "", "Answer API requests from a cassette file recorded with --record")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Log API requests to stderr")
+	rootCmd.PersistentFlags().Bool("debug", false, "Like --verbose, but also log request and response bodies")
+	rootCmd.PersistentFlags().Int("concurrency", 4, "Maximum number of concurrent API requests")
+	rootCmd.PersistentFlags().Bool("cache", false, "Cache station lookups and timetables on disk")
+	rootCmd.PersistentFlags().Bool("refresh", false, "Ignore cached responses, but update the cache")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Print results and errors as JSON")
+	rootCmd.PersistentFlags().DurationVar(&transferWarning, "transfer-warning", 5*time.Minute, "Warn about transfers shorter than this")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError("%s", err)
+	})
+
 	searchCmd.Flags().IntP("results", "n", 5, "Number of search results to display")
 	searchCmd.Flags().StringP("time", "t", "", "Departure time")
+	addConnectionFlags(searchCmd)
+	searchCmd.Flags().Var(&sortOrder, "sort", "Sort by departure, arrival, duration, transfers, best or occupancy (not by price)")
+	searchCmd.RegisterFlagCompletionFunc("sort", completeSortOrders)
+	searchCmd.Flags().BoolVar(&hideNotes, "no-notes", false, "Hide disruption notices and remarks")
+	searchCmd.Flags().Bool("offline", false, "Only look up stations in the local station index")
+	searchCmd.ValidArgsFunction = completeStations(0, 1)
 	rootCmd.AddCommand(searchCmd)
+
+	aliasAddCmd.ValidArgsFunction = completeStations(1, 1)
+	aliasRemoveCmd.ValidArgsFunction = completeAliases
+	aliasCmd.AddCommand(aliasAddCmd, aliasListCmd, aliasRemoveCmd, aliasSuggestCmd)
+	rootCmd.AddCommand(aliasCmd)
+
+	addConnectionFlags(routeAddCmd)
+	routeAddCmd.ValidArgsFunction = completeStations(1, 2)
+	routeRemoveCmd.ValidArgsFunction = completeRoutes
+	routeCmd.AddCommand(routeAddCmd, routeListCmd, routeRemoveCmd)
+	rootCmd.AddCommand(routeCmd)
+
+	goCmd.Flags().IntP("results", "n", 5, "Number of search results to display")
+	goCmd.Flags().StringP("time", "t", "", "Departure time")
+	goCmd.Flags().Var(&sortOrder, "sort", "Sort by departure, arrival, duration, transfers, best or occupancy (not by price)")
+	goCmd.RegisterFlagCompletionFunc("sort", completeSortOrders)
+	goCmd.Flags().BoolVar(&hideNotes, "no-notes", false, "Hide disruption notices and remarks")
+	goCmd.ValidArgsFunction = completeRoutes
+	rootCmd.AddCommand(goCmd)
+
+	nearbyCmd.Flags().IntP("results", "n", 10, "Maximum number of stations to display")
+	nearbyCmd.Flags().IntP("radius", "r", 1000, "Search radius in meters")
+	rootCmd.AddCommand(nearbyCmd)
+
+	historyCmd.Flags().IntP("results", "n", 20, "Number of searches to display")
+	historyRerunCmd.Flags().StringP("time", "t", "", "Departure time")
+	historyRerunCmd.Flags().Var(&sortOrder, "sort", "Sort by departure, arrival, duration, transfers, best or occupancy (not by price)")
+	historyRerunCmd.RegisterFlagCompletionFunc("sort", completeSortOrders)
+	historyRerunCmd.Flags().BoolVar(&hideNotes, "no-notes", false, "Hide disruption notices and remarks")
+	historyCmd.AddCommand(historyRerunCmd)
+	rootCmd.AddCommand(historyCmd)
+
+	rootCmd.AddCommand(completionCmd)
+
+	serveCmd.Flags().String("listen", ":8080", "Address to listen on")
+	serveCmd.Flags().StringSlice("cors-origin", nil, "Allow cross-origin requests from this origin (\"*\" for any), can be repeated")
+	serveCmd.Flags().Int("cache-size", 1000, "Number of responses cached in memory, 0 disables the cache (ignored with --cache)")
+	serveCmd.Flags().Duration("station-ttl", oebb.DefaultStationCacheTTL, "How long station lookups are cached")
+	serveCmd.Flags().Duration("timetable-ttl", oebb.DefaultTimetableCacheTTL, "How long connections and departures are cached")
+	rootCmd.AddCommand(serveCmd)
+
+	exporterCmd.Flags().String("listen", ":9180", "Address to serve metrics on")
+	exporterCmd.Flags().Duration("interval", time.Minute, "Time between queries")
+	exporterCmd.Flags().StringSlice("route", nil, "Saved route to export, can be repeated")
+	exporterCmd.Flags().StringSlice("station", nil, "Station whose departure board is exported, can be repeated")
+	exporterCmd.Flags().IntP("results", "n", exporter.DefaultResults, "Number of upcoming connections or departures to export")
+	exporterCmd.RegisterFlagCompletionFunc("route", completeRoutes)
+	rootCmd.AddCommand(exporterCmd)
+
+	monitorCmd.Flags().StringSlice("route", nil, "Saved route to watch, can be repeated")
+	monitorCmd.Flags().Duration("interval", 5*time.Minute, "Time between checks")
+	monitorCmd.Flags().String("schedule", "", "When to check, e.g. \"mon-fri 06:00-09:00\" (default always)")
+	monitorCmd.Flags().IntP("results", "n", monitor.DefaultResults, "Number of upcoming connections to watch per route")
+	monitorCmd.Flags().Duration("threshold", monitor.DefaultThreshold, "Minimum delay to notify about")
+	monitorCmd.Flags().Bool("notify-send", false, "Show desktop notifications using notify-send")
+	monitorCmd.Flags().StringSlice("webhook", nil, "POST events as JSON to this URL, can be repeated")
+	monitorCmd.Flags().StringArray("exec", nil, "Run this shell command for every event, can be repeated")
+	monitorCmd.Flags().Bool("once", false, "Check once and exit, e.g. when run by cron")
+	monitorCmd.RegisterFlagCompletionFunc("route", completeRoutes)
+	rootCmd.AddCommand(monitorCmd)
+
+	trainCmd.Flags().StringP("date", "d", "", "Date the train runs on, YYYY-MM-DD, today, tomorrow or yesterday (default today)")
+	rootCmd.AddCommand(trainCmd)
+
+	statsCmd.Flags().String("by", "train", "Group by train, weekday, hour or all")
+	statsCmd.Flags().String("train", "", "Only include this train or category, e.g. \"RJX 160\" or \"RJX\"")
+	statsCmd.Flags().String("station", "", "Only include stations whose name contains this")
+	statsCmd.Flags().Bool("arrivals", false, "Report arrival instead of departure delays")
+	statsCmd.Flags().Int("days", 0, "Only include trains observed in the last days (default all)")
+	statsCmd.Flags().Duration("threshold", delaylog.DefaultThreshold, "Delay below which trains count as punctual")
+	rootCmd.AddCommand(statsCmd)
+
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(reportError(err, jsonOutput))
 	}
 }
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func (r route) String() string {
+	str := r.From.Name + " -> " + r.To.Name
+	if desc := describeOptions(r.options()); len(desc) > 0 {
+		str += " (" + strings.Join(desc, ", ") + ")"
+	}
+	return str
+}
+
+// selectRoutes returns the names of the given saved routes, or of all saved
+// routes in alphabetical order if names is empty. Unknown routes are usage
+// errors.
+func selectRoutes(cfg *config, names []string) ([]string, error) {
+	if len(names) == 0 {
+		for name := range cfg.Routes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	for _, name := range names {
+		if _, ok := cfg.Routes[name]; !ok {
+			return nil, usageError("no such route: %s", name)
+		}
+	}
+	return names, nil
+}
+
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Manage saved routes",
+	Long: `Manage saved routes.
+
+A saved route can be searched using "go [route]". The stations of a route are
+looked up once when the route is added and stay fixed after that.`,
+}
+
+var routeAddCmd = &cobra.Command{
+	Use:   "add [name] [from] [to]",
+	Short: "Add or replace a saved route",
+	Args:  usageArgs(cobra.ExactArgs(3)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := connectionOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		resolver, err := newResolver()
+		if err != nil {
+			return err
+		}
+
+		stations, err := lookupStations(cfg, resolver, args[1], args[2])
+		if err != nil {
+			return err
+		}
+		saveStationIndex(resolver.Index)
+
+		r := route{
+			From:          stations[0],
+			To:            stations[1],
+			searchOptions: newSearchOptions(opts),
+		}
+
+		cfg.Routes[args[0]] = r
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: %s\n", bold(args[0]), r)
+
+		return nil
+	},
+}
+
+var routeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved routes",
+	Args:  usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(cfg.Routes))
+		for name := range cfg.Routes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Printf("%s: %s\n", bold(name), cfg.Routes[name])
+		}
+
+		return nil
+	},
+}
+
+var routeRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Remove a saved route",
+	Args:  usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := cfg.Routes[args[0]]; !ok {
+			return usageError("no such route: %s", args[0])
+		}
+
+		delete(cfg.Routes, args[0])
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+var goCmd = &cobra.Command{
+	Use:   "go [route]",
+	Short: "Search connections for a saved route",
+	Args:  usageArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		numResults, err := cmd.Flags().GetInt("results")
+		if err != nil {
+			return err
+		}
+
+		depTimeStr, err := cmd.Flags().GetString("time")
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		r, ok := cfg.Routes[args[0]]
+		if !ok {
+			return usageError("no such route: %s", args[0])
+		}
+
+		depTime, err := parseDepartureTime(depTimeStr)
+		if err != nil {
+			return err
+		}
+
+		s := newSpinner()
+		s.Start()
+
+		pAuth, err := maybeCachedAuth()
+		if err != nil {
+			s.Stop()
+			return err
+		}
+
+		return searchConnections(s, *pAuth, r.From, r.To, depTime, numResults, r.options())
+	},
+}
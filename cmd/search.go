@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -17,7 +18,7 @@ import (
 )
 
 func parseConnTime(str string) (time.Time, error) {
-	return time.Parse("2006-01-02T15:04:05.999", str)
+	return oebb.ParseTime(str)
 }
 
 func formatConnTime(str string) (string, error) {
@@ -91,20 +92,206 @@ func displaySection(section oebb.Section) error {
 		fmt.Println("\t" + formatDelayLine(depDelay, arrDelay, &dep, &arr))
 	}
 
+	times := rgbterm.InterpretStr(fmt.Sprintf("{#555555}%s{}{#555555}-{}{#555555}%s{}", dep, arr))
+
+	kind := section.Kind()
+	if !kind.Vehicle() {
+		fmt.Printf("\t%s %s\n", times, formatWalk(section, kind))
+		return nil
+	}
+
 	cname := section.Category.DisplayName
 	if cname == "" {
 		cname = section.Category.ShortName
 	}
+	if cname == "" {
+		cname = defaultCategoryNames[kind]
+	}
 	category := rgbterm.InterpretStr(fmt.Sprintf("\033[1m{#ffffff,%s}%-3s{}",
 		section.Category.BarColor,
 		strings.ToUpper(cname)),
 	)
 
-	times := rgbterm.InterpretStr(fmt.Sprintf("{#555555}%s{}{#555555}-{}{#555555}%s{}", dep, arr))
-	fmt.Printf("\t%s %s %s -> %s\n", times, category, section.From.Name, section.To.Name)
+	stations := section.From.Name + " -> " + section.To.Name
+	if kind == oebb.KindOnDemand {
+		stations += rgbterm.InterpretStr(" {#555555}(on demand, may need booking){}")
+	}
+	switch section.CancellationStatus() {
+	case oebb.Cancelled:
+		stations = strikethrough(stations) + " " + formatCancellation("cancelled", section.CancellationReason)
+	case oebb.PartiallyCancelled:
+		stations += " " + formatCancellation("partially cancelled", section.CancellationReason)
+	}
+	if occupancy := formatOccupancy(section.Occupancy); occupancy != "" {
+		stations += " " + occupancy
+	}
+	if accessibility := formatAccessibility(section.Accessibility); accessibility != "" {
+		stations += " " + accessibility
+	}
+	fmt.Printf("\t%s %s %s\n", times, category, stations)
 	return nil
 }
 
+// defaultCategoryNames are shown for sections without a category name.
+var defaultCategoryNames = map[oebb.SectionKind]string{
+	oebb.KindBus:      "Bus",
+	oebb.KindOnDemand: "Taxi",
+}
+
+// formatWalk describes a walk or a change within a station. Walks to a
+// different station are highlighted.
+func formatWalk(section oebb.Section, kind oebb.SectionKind) string {
+	duration := ""
+	if minutes := section.Duration / 1000 / 60; minutes > 0 {
+		duration = fmt.Sprintf(", %d min", minutes)
+	}
+	if kind == oebb.KindTransfer {
+		return rgbterm.InterpretStr(fmt.Sprintf("{#555555}change within %s%s{}", section.From.Name, duration))
+	}
+
+	str := "walk"
+	if section.Distance > 0 {
+		str += fmt.Sprintf(" %d m", section.Distance)
+	}
+	str += duration
+	if section.From.Name == section.To.Name {
+		return rgbterm.InterpretStr(fmt.Sprintf("{#555555}%s within %s{}", str, section.From.Name))
+	}
+	return rgbterm.InterpretStr(fmt.Sprintf("{#ffaa00}%s from %s to %s{}", str, section.From.Name, section.To.Name))
+}
+
+// formatAccessibility returns a compact description of the accessibility of a
+// train, e.g. "♿ step-free, 2 spaces", highlighting trains which require
+// assistance to be booked. It is empty if nothing is known.
+func formatAccessibility(a oebb.SectionAccessibility) string {
+	var parts []string
+	if a.StepFree {
+		parts = append(parts, "step-free")
+	}
+	if a.WheelchairSpaces > 0 {
+		parts = append(parts, fmt.Sprintf("%d spaces", a.WheelchairSpaces))
+	}
+
+	var str string
+	if len(parts) > 0 {
+		str = rgbterm.InterpretStr("{#555555}♿ " + strings.Join(parts, ", ") + "{}")
+	}
+	if a.AssistanceRequired {
+		if str != "" {
+			str += " "
+		}
+		str += rgbterm.InterpretStr("{#ffaa00}book assistance{}")
+	}
+	return str
+}
+
+// occupancyBars are the indicators of the occupancy levels, colored from green
+// to red.
+var occupancyBars = map[oebb.Occupancy]string{
+	oebb.OccupancyLow:    "{#00cc00}▂{}",
+	oebb.OccupancyMedium: "{#cccc00}▄{}",
+	oebb.OccupancyHigh:   "{#ff8800}▆{}",
+	oebb.OccupancyFull:   "{#ff0000}█{}",
+}
+
+// formatOccupancy returns a compact indicator of the occupancy of both
+// classes, e.g. "1▂ 2▆", or an empty string if it is unknown.
+func formatOccupancy(o oebb.ClassOccupancy) string {
+	var parts []string
+	if bar, ok := occupancyBars[o.First]; ok {
+		parts = append(parts, "1"+bar)
+	}
+	if bar, ok := occupancyBars[o.Second]; ok {
+		parts = append(parts, "2"+bar)
+	}
+	return rgbterm.InterpretStr(strings.Join(parts, " "))
+}
+
+// formatCancellation returns status, followed by the reason if known, in red.
+func formatCancellation(status, reason string) string {
+	if reason != "" {
+		status += ": " + reason
+	}
+	return rgbterm.InterpretStr("{#ff0000}(" + status + "){}")
+}
+
+// hideNotes disables displayNotes.
+var hideNotes bool
+
+// userLanguage returns the language of the user's locale as an ISO 639-1
+// code, e.g. "de" for "de_AT.UTF-8".
+func userLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(env); locale != "" && locale != "C" && locale != "POSIX" {
+			if len(locale) > 2 {
+				locale = locale[:2]
+			}
+			return locale
+		}
+	}
+	return "en"
+}
+
+// displayNotes prints notes in the user's language, colored by severity.
+func displayNotes(notes []oebb.Note, indent string) {
+	if hideNotes {
+		return
+	}
+
+	lang := userLanguage()
+	for _, note := range notes {
+		text := note.Text.In(lang)
+		if title := note.Title.In(lang); title != "" && title != text {
+			if text != "" {
+				text = title + ": " + text
+			} else {
+				text = title
+			}
+		}
+		if text == "" {
+			continue
+		}
+
+		switch note.Severity {
+		case oebb.NoteCritical:
+			text = rgbterm.InterpretStr("{#ff0000}! " + text + "{}")
+		case oebb.NoteWarning:
+			text = rgbterm.InterpretStr("{#ffaa00}! " + text + "{}")
+		default:
+			text = rgbterm.InterpretStr("{#555555}i " + text + "{}")
+		}
+		fmt.Println(indent + "      " + text)
+	}
+}
+
+// transferWarning is the transfer time below which transfers are highlighted.
+var transferWarning time.Duration
+
+// displayTransfer prints the time available for a transfer, highlighting
+// transfers shorter than transferWarning and transfers made impossible by
+// delays.
+func displayTransfer(t oebb.Transfer) {
+	str := fmt.Sprintf("change at %s, %d min", t.Station, int(t.Expected.Minutes()))
+	if t.Expected != t.Scheduled {
+		str += fmt.Sprintf(" (scheduled %d min)", int(t.Scheduled.Minutes()))
+	}
+	if t.Departure.From.Name != t.Station {
+		str += fmt.Sprintf(", continue from %s", t.Departure.From.Name)
+	} else if t.PlatformChange() {
+		str += fmt.Sprintf(", platform %s -> %s", t.ArrivalPlatform, t.DeparturePlatform)
+	}
+
+	switch {
+	case !t.Feasible(0):
+		str = rgbterm.InterpretStr("{#ff0000}" + str + ", connection will be missed{}")
+	case !t.Feasible(transferWarning):
+		str = rgbterm.InterpretStr("{#ffaa00}" + str + ", tight connection{}")
+	default:
+		str = rgbterm.InterpretStr("{#555555}" + str + "{}")
+	}
+	fmt.Println("\t      " + str)
+}
+
 func displayConnection(conn oebb.Connection) error {
 	dep, err := formatConnTime(conn.From.Departure)
 	if err != nil {
@@ -134,9 +321,29 @@ func displayConnection(conn oebb.Connection) error {
 	fromStr := bold(rgbterm.InterpretStr("{#cc6666}" + conn.From.Name + "{}"))
 	toStr := bold(rgbterm.InterpretStr("{#cc6666}" + conn.To.Name + "{}"))
 
-	fmt.Printf("%s-%s (%s) %s -> %s\n", dep, arr, durStr, fromStr, toStr)
+	status := ""
+	switch conn.CancellationStatus() {
+	case oebb.Cancelled:
+		status = " " + formatCancellation("cancelled", "")
+	case oebb.PartiallyCancelled:
+		status = " " + formatCancellation("partially cancelled", "")
+	}
+
+	fmt.Printf("%s-%s (%s) %s -> %s%s\n", dep, arr, durStr, fromStr, toStr, status)
+	displayNotes(conn.Notes, "")
+
+	transfers, err := conn.Transfers()
+	if err != nil {
+		return err
+	}
+
 	for _, section := range conn.Sections {
 		displaySection(section)
+		displayNotes(section.Notes, "\t")
+		if len(transfers) > 0 && transfers[0].Arrival.From == section.From && transfers[0].Arrival.To == section.To {
+			displayTransfer(transfers[0])
+			transfers = transfers[1:]
+		}
 	}
 
 	fmt.Println()
@@ -154,9 +361,9 @@ func cacheAuth(auth oebb.AuthInfo, filename string) error {
 }
 
 func authAndCache(filenameTemplate string) (*oebb.AuthInfo, error) {
-	auth, err := oebb.Auth()
+	auth, err := apiClient.Auth()
 	if err != nil {
-		return nil, err
+		return nil, authError(err)
 	}
 
 	newCache, err := xdg.CacheFile(filenameTemplate)
@@ -176,9 +383,14 @@ func maybeCachedAuth() (*oebb.AuthInfo, error) {
 	}
 
 	bytes, err := ioutil.ReadFile(cache)
+	if err != nil {
+		return authAndCache("oebb-cli/auth.json")
+	}
 
 	var newAuth oebb.AuthInfo
-	err = json.Unmarshal(bytes, &newAuth)
+	if err := json.Unmarshal(bytes, &newAuth); err != nil {
+		return authAndCache("oebb-cli/auth.json")
+	}
 
 	// check token expiration date and automatically renew if expired
 	stat, err := os.Stat(cache)
@@ -188,18 +400,23 @@ func maybeCachedAuth() (*oebb.AuthInfo, error) {
 
 	modTime := stat.ModTime()
 	if modTime.Add(time.Duration(newAuth.ExpiresIn) * time.Second).Before(time.Now()) {
+		logEvent("cached session expired, authenticating again")
 		return authAndCache("oebb-cli/auth.json")
 	}
 
-	return &newAuth, err
+	return &newAuth, nil
 }
 
+// handleTimeoutError authenticates again if e is a session timeout. It
+// reports whether the failed request should be retried with the new auth.
 func handleTimeoutError(e error, auth *oebb.AuthInfo) bool {
-	switch e.(type) {
-	case *oebb.SessionTimeoutError:
+	var timeoutErr *oebb.SessionTimeoutError
+	if errors.As(e, &timeoutErr) {
+		logEvent("session expired, authenticating again")
 		a, err := authAndCache("oebb-cli/auth.json")
 		if err != nil {
-			panic(e)
+			logEvent("failed to authenticate", "error", err)
+			return false
 		}
 
 		*auth = *a
@@ -220,93 +437,390 @@ func stopSpinnerOnCtrlC(s *spinner.Spinner) {
 	}()
 }
 
+// lookupStation resolves a station name given on the command line. Aliases
+// take precedence over the resolver. A coordinate given as "lat,lon" resolves
+// to the nearest station.
+func lookupStation(cfg *config, name string, resolver *oebb.Resolver) (oebb.Station, error) {
+	stations, err := lookupStations(cfg, resolver, name)
+	if err != nil {
+		return oebb.Station{}, err
+	}
+
+	return stations[0], nil
+}
+
+// lookupStations resolves several station names like lookupStation. Names
+// which need to be looked up using the API are resolved concurrently.
+func lookupStations(cfg *config, resolver *oebb.Resolver, names ...string) ([]oebb.Station, error) {
+	stations := make([]oebb.Station, len(names))
+
+	var (
+		pending []string
+		indices []int
+	)
+	for i, name := range names {
+		if station, ok := cfg.Aliases[name]; ok {
+			stations[i] = station
+			continue
+		}
+
+		if lat, lon, ok := parseCoordinate(name); ok {
+			station, err := nearestStation(lat, lon, resolver)
+			if err != nil {
+				return nil, err
+			}
+			stations[i] = station
+			continue
+		}
+
+		pending = append(pending, name)
+		indices = append(indices, i)
+	}
+
+	if len(pending) == 0 {
+		return stations, nil
+	}
+
+	results, err := resolver.ResolveAll(pending...)
+	if err != nil {
+		if handleTimeoutError(err, &resolver.Auth) {
+			results, err = resolver.ResolveAll(pending...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, result := range results {
+		if len(result) < 1 {
+			return nil, stationNotFoundError(pending[i])
+		}
+		stations[indices[i]] = result[0]
+	}
+
+	return stations, nil
+}
+
+// parseDepartureTime parses a departure time given as "15:04" on the current
+// day. An empty string means now.
+func parseDepartureTime(str string) (time.Time, error) {
+	if str == "" {
+		return time.Now(), nil
+	}
+
+	depTime, err := time.Parse("15:04", str)
+	if err != nil {
+		return time.Time{}, usageError("invalid departure time %q, expected HH:MM", str)
+	}
+
+	now := time.Now()
+	return depTime.AddDate(now.Year(), int(now.Month())-1, now.Day()-1), nil
+}
+
+func newSpinner() *spinner.Spinner {
+	s := spinner.New([]string{"|", "/", "-", "\\"}, 50*time.Millisecond, spinner.WithHiddenCursor(true))
+	s.Prefix = "Searching for connections "
+	s.Writer = os.Stderr
+	if verbose || jsonOutput {
+		// the spinner would garble the log or JSON output
+		s.Writer = ioutil.Discard
+		s.HideCursor = false
+	}
+
+	stopSpinnerOnCtrlC(s)
+
+	return s
+}
+
+// addConnectionFlags adds the flags read by connectionOptions to cmd.
+func addConnectionFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("direct", false, "Only show direct connections")
+	cmd.Flags().Duration("min-transfer", 0, "Minimum time for every transfer, e.g. 10m")
+	cmd.Flags().Int("max-transfers", -1, "Maximum number of transfers (default unlimited)")
+	cmd.Flags().Duration("max-duration", 0, "Maximum travel time, e.g. 3h")
+	cmd.Flags().StringSlice("only", nil, "Only use trains of these categories, e.g. RJ,ICE")
+	cmd.Flags().StringSlice("exclude", nil, "Skip connections using these categories, e.g. Bus")
+	cmd.Flags().Bool("show-cancelled", false, "Also show cancelled connections")
+	cmd.Flags().String("max-occupancy", "", "Hide connections with a train expected to be more crowded than low, medium or high")
+	cmd.Flags().Bool("first-class", false, "Use the occupancy of the first class")
+	cmd.Flags().Bool("wheelchair", false, "Only show connections accessible by wheelchair")
+	cmd.Flags().Bool("assistance-dog", false, "Travel with an assistance dog")
+}
+
+// connectionOptions returns the connection options given by the flags added
+// using addConnectionFlags.
+func connectionOptions(cmd *cobra.Command) (oebb.ConnectionOptions, error) {
+	var opts oebb.ConnectionOptions
+
+	direct, err := cmd.Flags().GetBool("direct")
+	if err != nil {
+		return opts, err
+	}
+	minTransfer, err := cmd.Flags().GetDuration("min-transfer")
+	if err != nil {
+		return opts, err
+	}
+	maxTransfers, err := cmd.Flags().GetInt("max-transfers")
+	if err != nil {
+		return opts, err
+	}
+	maxDuration, err := cmd.Flags().GetDuration("max-duration")
+	if err != nil {
+		return opts, err
+	}
+	only, err := cmd.Flags().GetStringSlice("only")
+	if err != nil {
+		return opts, err
+	}
+	exclude, err := cmd.Flags().GetStringSlice("exclude")
+	if err != nil {
+		return opts, err
+	}
+	showCancelled, err := cmd.Flags().GetBool("show-cancelled")
+	if err != nil {
+		return opts, err
+	}
+	maxOccupancy, err := cmd.Flags().GetString("max-occupancy")
+	if err != nil {
+		return opts, err
+	}
+	firstClass, err := cmd.Flags().GetBool("first-class")
+	if err != nil {
+		return opts, err
+	}
+	wheelchair, err := cmd.Flags().GetBool("wheelchair")
+	if err != nil {
+		return opts, err
+	}
+	assistanceDog, err := cmd.Flags().GetBool("assistance-dog")
+	if err != nil {
+		return opts, err
+	}
+
+	if minTransfer < 0 || maxDuration < 0 {
+		return opts, usageError("--min-transfer and --max-duration must not be negative")
+	}
+
+	opts.Direct = direct || maxTransfers == 0
+	opts.MinTransfer = minTransfer
+	opts.MaxDuration = maxDuration
+	opts.Only = only
+	opts.Exclude = exclude
+	opts.IncludeCancelled = showCancelled
+	opts.FirstClass = firstClass
+	opts.Accessibility.Wheelchair = wheelchair
+	opts.Accessibility.AssistanceDog = assistanceDog
+	if maxOccupancy != "" {
+		opts.MaxOccupancy, err = oebb.ParseOccupancy(maxOccupancy)
+		if err != nil || opts.MaxOccupancy == oebb.OccupancyUnknown || opts.MaxOccupancy == oebb.OccupancyFull {
+			return opts, usageError("invalid --max-occupancy %q, must be low, medium or high", maxOccupancy)
+		}
+	}
+	if maxTransfers > 0 {
+		opts.MaxTransfers = maxTransfers
+	}
+
+	return opts, nil
+}
+
+// describeOptions returns short descriptions of the options which differ from
+// the defaults.
+func describeOptions(opts oebb.ConnectionOptions) []string {
+	var desc []string
+	if opts.Direct {
+		desc = append(desc, "direct")
+	}
+	if opts.MinTransfer > 0 {
+		desc = append(desc, fmt.Sprintf("transfers >= %d min", int(opts.MinTransfer.Minutes())))
+	}
+	if opts.MaxTransfers > 0 {
+		desc = append(desc, fmt.Sprintf("max. %d transfers", opts.MaxTransfers))
+	}
+	if opts.MaxDuration > 0 {
+		desc = append(desc, fmt.Sprintf("max. %d min travel time", int(opts.MaxDuration.Minutes())))
+	}
+	if len(opts.Only) > 0 {
+		desc = append(desc, "only "+strings.Join(opts.Only, "/"))
+	}
+	if len(opts.Exclude) > 0 {
+		desc = append(desc, "no "+strings.Join(opts.Exclude, "/"))
+	}
+	if opts.IncludeCancelled {
+		desc = append(desc, "with cancelled")
+	}
+	if opts.MaxOccupancy != oebb.OccupancyUnknown {
+		desc = append(desc, "occupancy <= "+opts.MaxOccupancy.String())
+	}
+	if opts.FirstClass {
+		desc = append(desc, "first class")
+	}
+	if opts.Accessibility.Wheelchair {
+		desc = append(desc, "wheelchair")
+	}
+	if opts.Accessibility.AssistanceDog {
+		desc = append(desc, "assistance dog")
+	}
+	return desc
+}
+
+// sortOrderFlag is the value of --sort, one of sortOrders.
+type sortOrderFlag string
+
+func (f *sortOrderFlag) String() string {
+	return string(*f)
+}
+
+func (f *sortOrderFlag) Set(value string) error {
+	if value == "cheapest" || value == "price" {
+		return fmt.Errorf("sorting by price is not supported, the API returns no prices")
+	}
+	if _, ok := sortOrders[value]; !ok {
+		return fmt.Errorf("must be one of departure, arrival, duration, transfers, best or occupancy")
+	}
+	*f = sortOrderFlag(value)
+	return nil
+}
+
+func (f *sortOrderFlag) Type() string {
+	return "order"
+}
+
+// sortOrder is the order connections are displayed in.
+var sortOrder = sortOrderFlag("departure")
+
+// sortOrders are the values of --sort. They return the comparators for the
+// options of a search.
+var sortOrders = map[string]func(opts oebb.ConnectionOptions) []oebb.Less{
+	"departure": func(oebb.ConnectionOptions) []oebb.Less {
+		return []oebb.Less{oebb.ByDeparture}
+	},
+	"arrival": func(oebb.ConnectionOptions) []oebb.Less {
+		return []oebb.Less{oebb.ByArrival, oebb.ByDeparture}
+	},
+	"duration": func(oebb.ConnectionOptions) []oebb.Less {
+		return []oebb.Less{oebb.ByDuration, oebb.ByDeparture}
+	},
+	"transfers": func(oebb.ConnectionOptions) []oebb.Less {
+		return []oebb.Less{oebb.ByTransfers, oebb.ByDuration, oebb.ByDeparture}
+	},
+	"best": func(oebb.ConnectionOptions) []oebb.Less {
+		return []oebb.Less{oebb.Best(oebb.DefaultTransferPenalty), oebb.ByDeparture}
+	},
+	"occupancy": func(opts oebb.ConnectionOptions) []oebb.Less {
+		return []oebb.Less{oebb.ByOccupancy(opts.FirstClass), oebb.ByDeparture}
+	},
+}
+
+// searchConnections queries and displays connections between two already
+// resolved stations.
+func searchConnections(s *spinner.Spinner, auth oebb.AuthInfo, from, to oebb.Station, depTime time.Time, numResults int, opts oebb.ConnectionOptions) error {
+	if sortOrder == "arrival" {
+		opts.SortType = oebb.SortArrival
+	}
+
+	connections, err := apiClient.GetConnectionsWithOptions(from, to, auth, depTime, numResults, opts)
+	if err != nil && handleTimeoutError(err, &auth) {
+		connections, err = apiClient.GetConnectionsWithOptions(from, to, auth, depTime, numResults, opts)
+	}
+
+	s.Stop()
+	if err != nil {
+		return err
+	}
+
+	// the history is a convenience, so failing to save it is not fatal
+	recordHistory(historyEntry{
+		SearchedAt: time.Now(),
+		From:       from,
+		To:         to,
+		Departure:  depTime,
+		Results:    numResults,
+		Options:    newSearchOptions(opts),
+	})
+	recordDelays(connections)
+
+	if len(connections) < 1 {
+		if !jsonOutput {
+			errFrom := rgbterm.InterpretStr("{#cc6666}" + from.Name + "{}")
+			errTo := rgbterm.InterpretStr("{#cc6666}" + to.Name + "{}")
+			fmt.Printf("No connections found from %s to %s\n", errFrom, errTo)
+		}
+		return noConnectionsError(from, to)
+	}
+
+	oebb.SortConnections(connections, sortOrders[string(sortOrder)](opts)...)
+
+	if jsonOutput {
+		return printJSON(connections)
+	}
+
+	for _, conn := range connections {
+		if err := displayConnection(conn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 var searchCmd = &cobra.Command{
 	Use:   "search [from] [to]",
 	Short: "Search connections",
-	Args:  cobra.MinimumNArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		s := spinner.New([]string{"|", "/", "-", "\\"}, 50*time.Millisecond, spinner.WithHiddenCursor(true))
-		s.Prefix = "Searching for connections "
-		s.Writer = os.Stderr
-
-		stopSpinnerOnCtrlC(s)
-
-		s.Start()
+	Long: `Search connections between two stations.
 
+Stations can be given by name, by an alias defined with "alias add" or as a
+coordinate in degrees ("48.2,16.37"), which searches from the nearest station.`,
+	Args: usageArgs(cobra.MinimumNArgs(2)),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		numResults, err := cmd.Flags().GetInt("results")
 		if err != nil {
-			s.Stop()
-			panic(err)
+			return err
 		}
 
 		depTimeStr, err := cmd.Flags().GetString("time")
 		if err != nil {
-			s.Stop()
-			panic(err)
+			return err
 		}
 
-		pAuth, err := maybeCachedAuth()
+		opts, err := connectionOptions(cmd)
 		if err != nil {
-			s.Stop()
-			panic(err)
+			return err
 		}
 
-		auth := *pAuth
-
-		from := args[0]
-		to := args[1]
-
-		fromStation, err := oebb.GetStations(from, auth)
+		offline, err := cmd.Flags().GetBool("offline")
 		if err != nil {
-			if handleTimeoutError(err, &auth) == true {
-				fromStation, err = oebb.GetStations(from, auth)
-				if err != nil {
-					s.Stop()
-					panic(err)
-				}
-			}
+			return err
 		}
 
-		toStation, err := oebb.GetStations(to, auth)
+		depTime, err := parseDepartureTime(depTimeStr)
 		if err != nil {
-			if handleTimeoutError(err, &auth) {
-				toStation, err = oebb.GetStations(to, auth)
-				if err != nil {
-					s.Stop()
-					panic(err)
-				}
-			}
+			return err
 		}
 
-		var depTime time.Time
-		if depTimeStr == "" {
-			depTime = time.Now()
-		} else {
-			depTime, err = time.Parse("15:04", depTimeStr)
-			if err != nil {
-				s.Stop()
-				panic(err)
-			}
-
-			now := time.Now()
-			depTime = depTime.AddDate(now.Year(), int(now.Month())-1, now.Day()-1)
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
 		}
 
-		connections, err := oebb.GetConnections(fromStation[0], toStation[0], auth, depTime, numResults)
+		s := newSpinner()
+		s.Start()
+		defer s.Stop()
 
-		s.Stop()
+		resolver, err := newResolver()
 		if err != nil {
-			panic(err)
+			return err
 		}
+		resolver.Offline = offline
 
-		if len(connections) < 1 {
-			errFrom := rgbterm.InterpretStr("{#cc6666}" + fromStation[0].Name + "{}")
-			errTo := rgbterm.InterpretStr("{#cc6666}" + toStation[0].Name + "{}")
-			fmt.Printf("No connections found from %s to %s\n", errFrom, errTo)
+		stations, err := lookupStations(cfg, resolver, args[0], args[1])
+		if err != nil {
+			return err
 		}
+		fromStation, toStation := stations[0], stations[1]
 
-		for _, conn := range connections {
-			displayConnection(conn)
-		}
+		// the index only serves as a cache, so failing to save is not fatal
+		saveStationIndex(resolver.Index)
+
+		return searchConnections(s, resolver.Auth, fromStation, toStation, depTime, numResults, opts)
 	},
 }
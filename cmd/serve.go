@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/server"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a JSON REST API",
+	Long: `Serve a JSON REST API backed by the ÖBB Tickets API.
+
+Endpoints:
+  GET /stations?q=<name>
+  GET /connections?from=<name>&to=<name>[&time=<time>][&results=<n>][&direct=true]
+      [&wheelchair=true][&assistanceDog=true]
+  GET /departures/<station>[?time=<time>][&results=<n>]
+  GET /openapi.json
+
+All requests share one session and responses are cached in memory, or on
+disk with --cache.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, err := cmd.Flags().GetString("listen")
+		if err != nil {
+			return err
+		}
+
+		origins, err := cmd.Flags().GetStringSlice("cors-origin")
+		if err != nil {
+			return err
+		}
+
+		cacheSize, err := cmd.Flags().GetInt("cache-size")
+		if err != nil {
+			return err
+		}
+
+		stationTTL, err := cmd.Flags().GetDuration("station-ttl")
+		if err != nil {
+			return err
+		}
+
+		timetableTTL, err := cmd.Flags().GetDuration("timetable-ttl")
+		if err != nil {
+			return err
+		}
+
+		if apiClient.Cache == nil && cacheSize > 0 {
+			apiClient.Cache = oebb.NewCache(oebb.NewLRUStore(cacheSize))
+		}
+		if apiClient.Cache != nil {
+			apiClient.Cache.StationTTL = stationTTL
+			apiClient.Cache.TimetableTTL = timetableTTL
+		}
+
+		srv := server.New(oebb.NewSession(apiClient))
+		srv.AllowedOrigins = origins
+		srv.Logger = apiClient.Logger
+
+		return listenAndServe(listen, srv)
+	},
+}
+
+// listenAndServe serves h on addr until the process is interrupted or
+// terminated, then shuts the server down gracefully.
+func listenAndServe(addr string, h http.Handler) error {
+	httpServer := &http.Server{
+		Addr:    addr,
+		Handler: h,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		<-c
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+		close(done)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", addr)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+
+	<-done
+	return nil
+}
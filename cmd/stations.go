@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/adrg/xdg"
+	oebb "github.com/chrboe/oebb/client"
+)
+
+const (
+	stationIndexFile = "oebb-cli/station-index.json"
+	stationIndexTTL  = 30 * 24 * time.Hour
+)
+
+// loadStationIndex loads the local station index. If the index cannot be
+// read, an empty index is returned, as it only serves as a cache.
+func loadStationIndex() *oebb.StationIndex {
+	path, err := xdg.SearchCacheFile(stationIndexFile)
+	if err != nil {
+		return oebb.NewStationIndex(stationIndexTTL)
+	}
+
+	idx, err := oebb.LoadStationIndex(path, stationIndexTTL)
+	if err != nil {
+		return oebb.NewStationIndex(stationIndexTTL)
+	}
+
+	return idx
+}
+
+func saveStationIndex(idx *oebb.StationIndex) error {
+	path, err := xdg.CacheFile(stationIndexFile)
+	if err != nil {
+		return err
+	}
+
+	return idx.Save(path)
+}
+
+// newResolver returns a station resolver backed by the local station index.
+// The index should be saved using saveStationIndex when done.
+func newResolver() (*oebb.Resolver, error) {
+	pAuth, err := maybeCachedAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := oebb.NewResolver(loadStationIndex(), *pAuth)
+	resolver.Client = apiClient
+	return resolver, nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/delaylog"
+	"github.com/spf13/cobra"
+)
+
+const (
+	delayLogFile      = "oebb-cli/delays.jsonl"
+	delayLogRetention = 180 * 24 * time.Hour // how long to keep observations
+)
+
+// recordDelays appends the real-time information of connections to the delay
+// log and drops old and superseded observations. Like the history, it is a
+// convenience, so errors are only logged.
+func recordDelays(connections []oebb.Connection) {
+	now := time.Now()
+	observations := delaylog.Observe(connections, now)
+	if len(observations) == 0 {
+		return
+	}
+
+	path, err := xdg.DataFile(delayLogFile)
+	if err == nil {
+		err = delaylog.Append(path, observations...)
+	}
+	if err == nil {
+		err = delaylog.Prune(path, now.Add(-delayLogRetention))
+	}
+	if err != nil {
+		logEvent("failed to record delays", "error", err)
+	}
+}
+
+// statsGroups are the values of --by.
+var statsGroups = map[string]delaylog.KeyFunc{
+	"train":   delaylog.ByTrain,
+	"weekday": delaylog.ByWeekday,
+	"hour":    delaylog.ByHour,
+	"all":     delaylog.All,
+}
+
+// normalizeTrain makes train names comparable regardless of case and spacing,
+// so that "rjx160" matches "RJX 160".
+func normalizeTrain(train string) string {
+	return strings.ToLower(strings.Join(strings.Fields(train), ""))
+}
+
+// filterObservations returns the observations matching the stats flags.
+func filterObservations(observations []delaylog.Observation, train, station string, event delaylog.Event, since time.Time) []delaylog.Observation {
+	var filtered []delaylog.Observation
+	for _, o := range observations {
+		if train != "" && normalizeTrain(o.Train) != normalizeTrain(train) && normalizeTrain(o.Category) != normalizeTrain(train) {
+			continue
+		}
+		if station != "" && !strings.Contains(strings.ToLower(o.Station), strings.ToLower(station)) {
+			continue
+		}
+		if event != "" && o.Event != event {
+			continue
+		}
+		if o.ObservedAt.Before(since) {
+			continue
+		}
+		filtered = append(filtered, o)
+	}
+	return filtered
+}
+
+// statsJSON is a summary as printed with --json, with delays in minutes.
+type statsJSON struct {
+	Key         string  `json:"key"`
+	Count       int     `json:"count"`
+	Punctual    int     `json:"punctual"`
+	Cancelled   int     `json:"cancelled"`
+	Punctuality float64 `json:"punctuality"`
+	MeanMinutes float64 `json:"meanMinutes"`
+	P50Minutes  float64 `json:"p50Minutes"`
+	P90Minutes  float64 `json:"p90Minutes"`
+	P95Minutes  float64 `json:"p95Minutes"`
+}
+
+func formatMinutes(d time.Duration) string {
+	return fmt.Sprintf("%.1f", d.Minutes())
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show punctuality statistics of observed trains",
+	Long: `Show punctuality statistics of the trains seen in previous searches.
+
+Every search and every check of "monitor" records the real-time information
+of the trains it finds. "stats" reports, per train, weekday or hour, how many
+trains were punctual and how late they were on average and in the 50th, 90th
+and 95th percentile. Delays are in minutes.
+
+Only trains with real-time information are recorded, and only the latest
+observation of every departure and arrival counts. Trains are kept for 180
+days.`,
+	Args: usageArgs(cobra.NoArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		by, err := cmd.Flags().GetString("by")
+		if err != nil {
+			return err
+		}
+		train, err := cmd.Flags().GetString("train")
+		if err != nil {
+			return err
+		}
+		station, err := cmd.Flags().GetString("station")
+		if err != nil {
+			return err
+		}
+		arrivals, err := cmd.Flags().GetBool("arrivals")
+		if err != nil {
+			return err
+		}
+		days, err := cmd.Flags().GetInt("days")
+		if err != nil {
+			return err
+		}
+		threshold, err := cmd.Flags().GetDuration("threshold")
+		if err != nil {
+			return err
+		}
+
+		key, ok := statsGroups[by]
+		if !ok {
+			return usageError("invalid --by %q, must be one of train, weekday, hour or all", by)
+		}
+
+		event := delaylog.Departure
+		if arrivals {
+			event = delaylog.Arrival
+		}
+
+		var since time.Time
+		if days > 0 {
+			since = time.Now().AddDate(0, 0, -days)
+		}
+
+		var observations []delaylog.Observation
+		if path, err := xdg.SearchDataFile(delayLogFile); err == nil {
+			if observations, err = delaylog.Load(path); err != nil {
+				return err
+			}
+		}
+
+		observations = filterObservations(delaylog.Latest(observations), train, station, event, since)
+		summaries := delaylog.Summarize(observations, key, threshold)
+
+		if jsonOutput {
+			stats := make([]statsJSON, 0, len(summaries))
+			for _, s := range summaries {
+				stats = append(stats, statsJSON{
+					Key:         s.Key,
+					Count:       s.Count,
+					Punctual:    s.Punctual,
+					Cancelled:   s.Cancelled,
+					Punctuality: s.Punctuality,
+					MeanMinutes: s.Mean.Minutes(),
+					P50Minutes:  s.P50.Minutes(),
+					P90Minutes:  s.P90.Minutes(),
+					P95Minutes:  s.P95.Minutes(),
+				})
+			}
+			return printJSON(stats)
+		}
+
+		if len(summaries) == 0 {
+			fmt.Println("No delays recorded yet. Trains are recorded by \"search\", \"go\" and \"monitor\".")
+			return nil
+		}
+
+		fmt.Printf("%-12s %6s %9s %9s %6s %6s %6s %6s\n", by, "count", "punctual", "cancelled", "mean", "p50", "p90", "p95")
+		for _, s := range summaries {
+			fmt.Printf("%-12s %6d %8.1f%% %9d %6s %6s %6s %6s\n",
+				s.Key,
+				s.Count,
+				100*s.Punctuality,
+				s.Cancelled,
+				formatMinutes(s.Mean),
+				formatMinutes(s.P50),
+				formatMinutes(s.P90),
+				formatMinutes(s.P95))
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aybabtme/rgbterm"
+	oebb "github.com/chrboe/oebb/client"
+	"github.com/spf13/cobra"
+)
+
+// parseDate parses a date given as YYYY-MM-DD, "today", "tomorrow" or
+// "yesterday". An empty string means today.
+func parseDate(str string) (time.Time, error) {
+	now := time.Now()
+	switch strings.ToLower(str) {
+	case "", "today":
+		return now, nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", str, time.Local)
+	if err != nil {
+		return time.Time{}, usageError("invalid date %q, expected YYYY-MM-DD, today, tomorrow or yesterday", str)
+	}
+	return date, nil
+}
+
+// formatStopTime formats the scheduled time of a stop, followed by the
+// expected time in red if it differs. Missing times are left blank.
+func formatStopTime(scheduled, actual string) (string, error) {
+	if scheduled == "" {
+		return strings.Repeat(" ", 11), nil
+	}
+
+	str, err := formatConnTime(scheduled)
+	if err != nil {
+		return "", err
+	}
+
+	if actual != "" && actual != scheduled {
+		delayed, err := formatDelayTime(actual)
+		if err != nil {
+			return "", err
+		}
+		return strikethrough(str) + " " + delayed, nil
+	}
+	return str + strings.Repeat(" ", 6), nil
+}
+
+// formatStopPlatform returns the departure platform of a stop, or the arrival
+// platform at the last stop, in red if it changed.
+func formatStopPlatform(stop oebb.Stop) string {
+	dep, arr := stop.AsDeparture(), stop.AsArrival()
+	switch {
+	case dep.PlatformChanged():
+		return rgbterm.InterpretStr("{#ff0000}" + dep.DeparturePlatformDeviation + "{}")
+	case dep.DeparturePlatform != "":
+		return dep.DeparturePlatform
+	case arr.PlatformChanged():
+		return rgbterm.InterpretStr("{#ff0000}" + arr.ArrivalPlatformDeviation + "{}")
+	default:
+		return arr.ArrivalPlatform
+	}
+}
+
+func displayJourney(j oebb.Journey) error {
+	name := j.Category.DisplayName
+	if name == "" {
+		name = j.Category.ShortName
+	}
+	title := bold(strings.TrimSpace(name+" "+j.Category.Number)) + " -> " + bold(j.Direction)
+	if j.Cancelled {
+		title += " " + formatCancellation("cancelled", "")
+	}
+	fmt.Println(title)
+	displayNotes(j.Notes, "")
+
+	for _, stop := range j.Stops {
+		arr, err := formatStopTime(stop.Arrival, stop.ArrivalDelay)
+		if err != nil {
+			return err
+		}
+		dep, err := formatStopTime(stop.Departure, stop.DepartureDelay)
+		if err != nil {
+			return err
+		}
+
+		station := stop.Name
+		if stop.Cancelled {
+			station = strikethrough(station) + " " + formatCancellation("no stop", "")
+		}
+
+		platform := formatStopPlatform(stop)
+		if platform != "" {
+			station += rgbterm.InterpretStr(" {#555555}platform{} ") + platform
+		}
+
+		fmt.Printf("\t%s %s %s\n", arr, dep, station)
+	}
+
+	fmt.Println()
+	return nil
+}
+
+var trainCmd = &cobra.Command{
+	Use:   "train TRAIN",
+	Short: "Show the route of a train",
+	Long: `Show all stops of a train with real-time information, e.g.
+
+  oebb-cli train RJX160 --date tomorrow
+
+The train is given by its category and number, with or without a space.`,
+	Args: usageArgs(cobra.MinimumNArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dateStr, err := cmd.Flags().GetString("date")
+		if err != nil {
+			return err
+		}
+
+		date, err := parseDate(dateStr)
+		if err != nil {
+			return err
+		}
+
+		category, number, err := oebb.ParseTrain(strings.Join(args, " "))
+		if err != nil {
+			return usageError("%s", err)
+		}
+		train := category + " " + number
+
+		s := newSpinner()
+		s.Start()
+
+		pAuth, err := maybeCachedAuth()
+		if err != nil {
+			s.Stop()
+			return err
+		}
+
+		journeys, err := apiClient.GetJourneys(category, number, *pAuth, date)
+		if err != nil && handleTimeoutError(err, pAuth) {
+			journeys, err = apiClient.GetJourneys(category, number, *pAuth, date)
+		}
+
+		s.Stop()
+		if err != nil {
+			return err
+		}
+
+		if len(journeys) == 0 {
+			return trainNotFoundError(train, date)
+		}
+
+		if jsonOutput {
+			return printJSON(journeys)
+		}
+
+		for _, j := range journeys {
+			if err := displayJourney(j); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
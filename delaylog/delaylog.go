@@ -0,0 +1,233 @@
+// Package delaylog records the scheduled and actual times of trains observed
+// in search results and computes punctuality statistics from them.
+//
+// Observations are appended to a JSON Lines file. A train is usually observed
+// several times before it departs, so only the latest observation of every
+// departure or arrival is used for statistics. Prune keeps the file from
+// growing without limit.
+package delaylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+)
+
+// Event is the kind of stop an observation is about.
+type Event string
+
+// The kinds of stops.
+const (
+	Departure Event = "departure"
+	Arrival   Event = "arrival"
+)
+
+// Observation is the scheduled and expected time of a train departing from or
+// arriving at a station, as observed at a certain time.
+//
+// Scheduled and Actual are in the local time of the timetable, even though
+// they are stored as UTC.
+type Observation struct {
+	ObservedAt time.Time `json:"observedAt"`
+	Train      string    `json:"train"`
+	Category   string    `json:"category"`
+	Number     string    `json:"number"`
+	Station    string    `json:"station"`
+	Event      Event     `json:"event"`
+	Scheduled  time.Time `json:"scheduled"`
+	Actual     time.Time `json:"actual"`
+	Cancelled  bool      `json:"cancelled,omitempty"`
+}
+
+// Delay returns how much later than scheduled the train departed or arrived.
+func (o Observation) Delay() time.Duration {
+	return o.Actual.Sub(o.Scheduled)
+}
+
+// key identifies the stop an observation is about.
+func (o Observation) key() string {
+	return strings.Join([]string{o.Train, o.Station, string(o.Event), o.Scheduled.Format(time.RFC3339)}, "|")
+}
+
+// observe returns the observation of a stop, or false if there is no
+// real-time information about it.
+func observe(now time.Time, s client.Section, event Event, station, scheduled, actual string) (Observation, bool) {
+	if !s.HasRealtime && actual == "" && !s.Cancelled {
+		return Observation{}, false
+	}
+
+	sched, err := client.ParseTime(scheduled)
+	if err != nil {
+		return Observation{}, false
+	}
+
+	act := sched
+	if actual != "" {
+		if act, err = client.ParseTime(actual); err != nil {
+			return Observation{}, false
+		}
+	}
+
+	category := s.Category.DisplayName
+	if category == "" {
+		category = s.Category.ShortName
+	}
+
+	return Observation{
+		ObservedAt: now,
+		Train:      strings.TrimSpace(category + " " + s.Category.Number),
+		Category:   category,
+		Number:     s.Category.Number,
+		Station:    station,
+		Event:      event,
+		Scheduled:  sched,
+		Actual:     act,
+		Cancelled:  s.Cancelled,
+	}, true
+}
+
+// Observe returns the observations of the departures and arrivals of all trains
+// in connections, observed at now. Sections without real-time information
+// and walks are skipped.
+func Observe(connections []client.Connection, now time.Time) []Observation {
+	var observations []Observation
+	seen := map[string]bool{}
+	for _, conn := range connections {
+		for _, s := range conn.Sections {
+			if !s.Kind().Vehicle() {
+				continue
+			}
+
+			if o, ok := observe(now, s, Departure, s.From.Name, s.From.Departure, s.From.DepartureDelay); ok && !seen[o.key()] {
+				seen[o.key()] = true
+				observations = append(observations, o)
+			}
+			if o, ok := observe(now, s, Arrival, s.To.Name, s.To.Arrival, s.To.ArrivalDelay); ok && !seen[o.key()] {
+				seen[o.key()] = true
+				observations = append(observations, o)
+			}
+		}
+	}
+	return observations
+}
+
+// Append appends observations to the log at path.
+func Append(path string, observations ...Observation) error {
+	if len(observations) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, o := range observations {
+		if err := enc.Encode(o); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads all observations from the log at path. A missing log contains no
+// observations. Lines which cannot be parsed, e.g. because writing them was
+// interrupted, are skipped.
+func Load(path string) ([]Observation, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var observations []Observation
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var o Observation
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			continue
+		}
+		observations = append(observations, o)
+	}
+
+	return observations, scanner.Err()
+}
+
+// Prune removes observations made before since from the log at path, along
+// with observations superseded by a later one of the same stop. The log is
+// only rewritten if anything was removed.
+func Prune(path string, since time.Time) error {
+	observations, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	var kept []Observation
+	for _, o := range Latest(observations) {
+		if !o.ObservedAt.Before(since) {
+			kept = append(kept, o)
+		}
+	}
+	if len(kept) == len(observations) {
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, o := range kept {
+		if err := enc.Encode(o); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Latest returns only the latest observation of every stop, in the order of
+// their first observation.
+func Latest(observations []Observation) []Observation {
+	index := map[string]int{}
+	var latest []Observation
+	for _, o := range observations {
+		i, ok := index[o.key()]
+		if !ok {
+			index[o.key()] = len(latest)
+			latest = append(latest, o)
+			continue
+		}
+		if !o.ObservedAt.Before(latest[i].ObservedAt) {
+			latest[i] = o
+		}
+	}
+	return latest
+}
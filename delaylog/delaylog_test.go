@@ -0,0 +1,145 @@
+package delaylog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+var start = oebbtest.Start // a Monday
+
+func TestObserve(t *testing.T) {
+	connections := []client.Connection{{Sections: []client.Section{
+		{
+			From:        client.DepartureStation{Name: "Wien Hbf", Departure: oebbtest.At(0), DepartureDelay: oebbtest.At(4)},
+			To:          client.ArrivalStation{Name: "Graz Hbf", Arrival: oebbtest.At(155)},
+			Category:    client.Category{DisplayName: "RJ", Number: "553"},
+			HasRealtime: true,
+		},
+		// walk
+		{From: client.DepartureStation{Departure: oebbtest.At(155)}, To: client.ArrivalStation{Arrival: oebbtest.At(160)}, HasRealtime: true},
+		// no real-time information
+		{
+			From:     client.DepartureStation{Name: "Graz Hbf", Departure: oebbtest.At(170)},
+			To:       client.ArrivalStation{Name: "Leoben Hbf", Arrival: oebbtest.At(210)},
+			Category: client.Category{DisplayName: "S", Number: "1"},
+		},
+	}}}
+
+	// the same train twice is observed once
+	observations := Observe(append(connections, connections...), start)
+	if len(observations) != 2 {
+		t.Fatalf("got %d observations, want 2: %+v", len(observations), observations)
+	}
+
+	dep, arr := observations[0], observations[1]
+	if dep.Train != "RJ 553" || dep.Event != Departure || dep.Station != "Wien Hbf" || dep.Delay() != 4*time.Minute {
+		t.Errorf("got departure %+v", dep)
+	}
+	if arr.Event != Arrival || arr.Station != "Graz Hbf" || arr.Delay() != 0 {
+		t.Errorf("got arrival %+v", arr)
+	}
+}
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delays.jsonl")
+
+	observations, err := Load(path)
+	if err != nil || len(observations) != 0 {
+		t.Fatalf("Load() of missing log returned %v, %v", observations, err)
+	}
+
+	o := Observation{ObservedAt: start, Train: "RJ 553", Station: "Wien Hbf", Event: Departure, Scheduled: start, Actual: start.Add(2 * time.Minute)}
+	later := o
+	later.ObservedAt = start.Add(time.Minute)
+	later.Actual = start.Add(6 * time.Minute)
+
+	if err := Append(path, o); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(path, later); err != nil {
+		t.Fatal(err)
+	}
+
+	observations, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(observations) != 2 {
+		t.Fatalf("got %d observations, want 2", len(observations))
+	}
+
+	latest := Latest(observations)
+	if len(latest) != 1 || latest[0].Delay() != 6*time.Minute {
+		t.Errorf("got latest %+v, want the later observation", latest)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "delays.jsonl")
+
+	if err := Prune(path, start); err != nil {
+		t.Fatalf("Prune() of missing log failed: %v", err)
+	}
+
+	old := Observation{ObservedAt: start.AddDate(0, 0, -1), Train: "RJ 551", Station: "Wien Hbf", Event: Departure, Scheduled: start.AddDate(0, 0, -1)}
+	o := Observation{ObservedAt: start, Train: "RJ 553", Station: "Wien Hbf", Event: Departure, Scheduled: start, Actual: start.Add(2 * time.Minute)}
+	later := o
+	later.ObservedAt = start.Add(time.Minute)
+	later.Actual = start.Add(6 * time.Minute)
+	if err := Append(path, old, o, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Prune(path, start); err != nil {
+		t.Fatalf("Prune() failed: %v", err)
+	}
+
+	observations, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(observations) != 1 || observations[0].Delay() != 6*time.Minute {
+		t.Errorf("kept %+v, want only the later observation", observations)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var observations []Observation
+	for i, delay := range []int{0, 1, 2, 3, 4, 5, 6, 8, 10, 21} {
+		scheduled := start.AddDate(0, 0, i%2) // Monday and Tuesday
+		observations = append(observations, Observation{
+			Train:     "RJ 553",
+			Scheduled: scheduled,
+			Actual:    scheduled.Add(time.Duration(delay) * time.Minute),
+		})
+	}
+	observations = append(observations, Observation{Train: "RJ 553", Scheduled: start, Actual: start, Cancelled: true})
+
+	summaries := Summarize(observations, All, DefaultThreshold)
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(summaries))
+	}
+
+	s := summaries[0]
+	if s.Count != 11 || s.Punctual != 6 || s.Cancelled != 1 {
+		t.Errorf("got count %d, punctual %d, cancelled %d, want 11, 6, 1", s.Count, s.Punctual, s.Cancelled)
+	}
+	if s.Mean != 6*time.Minute || s.P50 != 4*time.Minute || s.P90 != 10*time.Minute || s.P95 != 21*time.Minute {
+		t.Errorf("got mean %v, p50 %v, p90 %v, p95 %v", s.Mean, s.P50, s.P90, s.P95)
+	}
+
+	// weekdays are ordered from Monday
+	sunday := Observation{Scheduled: start.AddDate(0, 0, 6), Actual: start.AddDate(0, 0, 6)}
+	summaries = Summarize(append(observations, sunday), ByWeekday, DefaultThreshold)
+	var keys []string
+	for _, s := range summaries {
+		keys = append(keys, s.Key)
+	}
+	if len(keys) != 3 || keys[0] != "Monday" || keys[1] != "Tuesday" || keys[2] != "Sunday" {
+		t.Errorf("got weekdays %v", keys)
+	}
+}
@@ -0,0 +1,134 @@
+package delaylog
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultThreshold is the delay below which trains count as punctual. The
+// ÖBB count trains as punctual if they are less than 5:30 minutes late.
+const DefaultThreshold = 5*time.Minute + 30*time.Second
+
+// Summary holds the punctuality statistics of a group of observations.
+type Summary struct {
+	Key string
+	// Count is the number of observations, including cancelled trains.
+	Count     int
+	Punctual  int
+	Cancelled int
+	// Punctuality is the fraction of trains which were not cancelled and
+	// less than the threshold late.
+	Punctuality float64
+	// Mean and the percentiles are computed from the trains which were not
+	// cancelled.
+	Mean          time.Duration
+	P50, P90, P95 time.Duration
+}
+
+// KeyFunc returns the group an observation belongs to.
+type KeyFunc func(o Observation) string
+
+// ByTrain groups observations by train.
+func ByTrain(o Observation) string {
+	return o.Train
+}
+
+// ByWeekday groups observations by the weekday of the scheduled time.
+func ByWeekday(o Observation) string {
+	return o.Scheduled.Weekday().String()
+}
+
+// ByHour groups observations by the hour of the scheduled time.
+func ByHour(o Observation) string {
+	return o.Scheduled.Format("15:00")
+}
+
+// All puts all observations into a single group.
+func All(o Observation) string {
+	return "all"
+}
+
+// Summarize computes the statistics of observations, grouped by key. Trains
+// less than threshold late count as punctual. The summaries are ordered by
+// key, except for weekdays which are ordered from Monday to Sunday.
+func Summarize(observations []Observation, key KeyFunc, threshold time.Duration) []Summary {
+	groups := map[string][]Observation{}
+	var keys []string
+	for _, o := range observations {
+		k := key(o)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], o)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		wi, iok := weekdays[keys[i]]
+		wj, jok := weekdays[keys[j]]
+		if iok && jok {
+			return wi < wj
+		}
+		return keys[i] < keys[j]
+	})
+
+	summaries := make([]Summary, 0, len(keys))
+	for _, k := range keys {
+		summaries = append(summaries, summarize(k, groups[k], threshold))
+	}
+	return summaries
+}
+
+// weekdays maps the names of weekdays to their position in a week starting
+// on Monday.
+var weekdays = map[string]int{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		weekdays[d.String()] = (int(d) + 6) % 7
+	}
+}
+
+func summarize(key string, observations []Observation, threshold time.Duration) Summary {
+	s := Summary{Key: key, Count: len(observations)}
+
+	var delays []time.Duration
+	var total time.Duration
+	for _, o := range observations {
+		if o.Cancelled {
+			s.Cancelled++
+			continue
+		}
+
+		d := o.Delay()
+		if d < threshold {
+			s.Punctual++
+		}
+		delays = append(delays, d)
+		total += d
+	}
+
+	if s.Count > 0 {
+		s.Punctuality = float64(s.Punctual) / float64(s.Count)
+	}
+
+	if len(delays) > 0 {
+		sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+		s.Mean = total / time.Duration(len(delays))
+		s.P50 = percentile(delays, 0.5)
+		s.P90 = percentile(delays, 0.9)
+		s.P95 = percentile(delays, 0.95)
+	}
+
+	return s
+}
+
+// percentile returns the p-th percentile of sorted delays, using the nearest
+// rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,277 @@
+// Package exporter periodically queries routes and departure boards and
+// exposes their delays, cancellations and platform changes as Prometheus
+// metrics, along with the latency and errors of the API requests made.
+//
+// Metrics are written in the Prometheus text exposition format, so no client
+// library is needed.
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+)
+
+// DefaultResults is the default number of upcoming connections or departures
+// queried per route or station.
+const DefaultResults = 5
+
+// Route is a connection search whose results are exported.
+type Route struct {
+	// Name is used as the route label of the metrics.
+	Name     string
+	From, To client.Station
+	Options  client.ConnectionOptions
+}
+
+// Exporter queries routes and departure boards and serves the results as
+// Prometheus metrics. Create it using New.
+type Exporter struct {
+	// Session is used for all requests.
+	Session *client.Session
+
+	// Routes are searched for the next Results connections.
+	Routes []Route
+
+	// Stations are queried for the next Results departures.
+	Stations []client.Station
+
+	// Results is the number of connections or departures queried per route
+	// or station. If zero, DefaultResults is used.
+	Results int
+
+	// Logger receives an event for every failed query, if set.
+	Logger client.Logger
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+
+	mu       sync.Mutex
+	families []*family
+	requests map[string]*requestStats
+}
+
+// requestStats counts the API requests of one kind.
+type requestStats struct {
+	count    uint64
+	errors   uint64
+	duration time.Duration
+}
+
+// New returns an exporter using session. Set its Routes and Stations before
+// collecting.
+func New(session *client.Session) *Exporter {
+	return &Exporter{
+		Session:  session,
+		now:      time.Now,
+		requests: map[string]*requestStats{},
+	}
+}
+
+func (e *Exporter) results() int {
+	if e.Results > 0 {
+		return e.Results
+	}
+	return DefaultResults
+}
+
+func (e *Exporter) log(msg string, keyvals ...interface{}) {
+	if e.Logger != nil {
+		e.Logger.Log(msg, keyvals...)
+	}
+}
+
+// do runs an API request of the given kind and records its duration and
+// whether it failed.
+func (e *Exporter) do(kind string, f func(c *client.Client, a client.AuthInfo) error) error {
+	start := time.Now()
+	err := e.Session.Do(f)
+	duration := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stats, ok := e.requests[kind]
+	if !ok {
+		stats = &requestStats{}
+		e.requests[kind] = stats
+	}
+	stats.count++
+	stats.duration += duration
+	if err != nil {
+		stats.errors++
+	}
+
+	return err
+}
+
+// categoryLabels returns the category and number labels of a train.
+func categoryLabels(c client.Category) []label {
+	name := c.DisplayName
+	if name == "" {
+		name = c.ShortName
+	}
+	return []label{{"category", name}, {"number", c.Number}}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Collect queries all routes and stations once and replaces the exported
+// values with the results. Routes and stations which could not be queried are
+// exported as down and without any other values.
+func (e *Exporter) Collect() {
+	start := time.Now()
+	now := e.now()
+
+	routeUp := &family{name: "oebb_route_up", help: "Whether the last search for the route succeeded.", typ: gauge}
+	depDelay := &family{name: "oebb_departure_delay_seconds", help: "Departure delay of a train on a route.", typ: gauge}
+	arrDelay := &family{name: "oebb_arrival_delay_seconds", help: "Arrival delay of a train on a route.", typ: gauge}
+	cancelled := &family{name: "oebb_cancelled", help: "Whether a train on a route is cancelled.", typ: gauge}
+	platformChanged := &family{name: "oebb_platform_changed", help: "Whether a train on a route departs from a different platform than scheduled.", typ: gauge}
+
+	for _, r := range e.Routes {
+		// cancelled trains are only returned when asked for
+		opts := r.Options
+		opts.IncludeCancelled = true
+
+		var connections []client.Connection
+		err := e.do("connections", func(c *client.Client, a client.AuthInfo) error {
+			var err error
+			connections, err = c.GetConnectionsWithOptions(r.From, r.To, a, now, e.results(), opts)
+			return err
+		})
+		routeUp.add(boolValue(err == nil), label{"route", r.Name})
+		if err != nil {
+			e.log("failed to search route", "route", r.Name, "error", err)
+			continue
+		}
+
+		// the same train can be part of several connections
+		seen := map[string]bool{}
+		for _, conn := range connections {
+			for _, s := range conn.Sections {
+				if !s.Kind().Vehicle() {
+					continue
+				}
+
+				labels := append([]label{{"route", r.Name}}, categoryLabels(s.Category)...)
+				key := formatLabels(labels)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				if d, err := s.From.Delay(); err == nil {
+					depDelay.add(d.Seconds(), labels...)
+				}
+				if d, err := s.To.Delay(); err == nil {
+					arrDelay.add(d.Seconds(), labels...)
+				}
+				cancelled.add(boolValue(s.Cancelled), labels...)
+				platformChanged.add(boolValue(s.From.PlatformChanged()), labels...)
+			}
+		}
+	}
+
+	boardUp := &family{name: "oebb_board_up", help: "Whether the last query of the departure board succeeded.", typ: gauge}
+	boardDelay := &family{name: "oebb_board_departure_delay_seconds", help: "Departure delay of a train on a departure board.", typ: gauge}
+	boardCancelled := &family{name: "oebb_board_cancelled", help: "Whether a train on a departure board is cancelled.", typ: gauge}
+	boardPlatformChanged := &family{name: "oebb_board_platform_changed", help: "Whether a train on a departure board departs from a different platform than scheduled.", typ: gauge}
+
+	for _, st := range e.Stations {
+		var departures []client.Departure
+		err := e.do("departures", func(c *client.Client, a client.AuthInfo) error {
+			var err error
+			departures, err = c.GetDepartures(st, a, now, e.results())
+			return err
+		})
+		boardUp.add(boolValue(err == nil), label{"station", st.Name})
+		if err != nil {
+			e.log("failed to query departure board", "station", st.Name, "error", err)
+			continue
+		}
+
+		seen := map[string]bool{}
+		for _, d := range departures {
+			labels := append([]label{{"station", st.Name}}, categoryLabels(d.Category)...)
+			labels = append(labels, label{"direction", d.Direction})
+			key := formatLabels(labels)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if delay, err := d.Delay(); err == nil {
+				boardDelay.add(delay.Seconds(), labels...)
+			}
+			boardCancelled.add(boolValue(d.Cancelled), labels...)
+			boardPlatformChanged.add(boolValue(d.PlatformChanged()), labels...)
+		}
+	}
+
+	lastCollection := &family{name: "oebb_exporter_last_collection_timestamp_seconds", help: "Time of the last collection.", typ: gauge}
+	lastCollection.add(float64(now.Unix()))
+	collectionDuration := &family{name: "oebb_exporter_collection_duration_seconds", help: "Duration of the last collection.", typ: gauge}
+	collectionDuration.add(time.Since(start).Seconds())
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.families = []*family{
+		routeUp, depDelay, arrDelay, cancelled, platformChanged,
+		boardUp, boardDelay, boardCancelled, boardPlatformChanged,
+		lastCollection, collectionDuration,
+	}
+}
+
+// Run collects immediately and then every interval, until ctx is done.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	e.Collect()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Collect()
+		}
+	}
+}
+
+// requestFamilies returns the metrics about API requests. It must be called
+// with e.mu held.
+func (e *Exporter) requestFamilies() []*family {
+	requests := &family{name: "oebb_api_requests_total", help: "API queries made.", typ: counter}
+	errors := &family{name: "oebb_api_errors_total", help: "API queries which failed.", typ: counter}
+	duration := &family{name: "oebb_api_request_duration_seconds", help: "Duration of API queries.", typ: summary}
+
+	for kind, stats := range e.requests {
+		l := label{"operation", kind}
+		requests.add(float64(stats.count), l)
+		errors.add(float64(stats.errors), l)
+		duration.addSuffix("_sum", stats.duration.Seconds(), l)
+		duration.addSuffix("_count", float64(stats.count), l)
+	}
+
+	return []*family{requests, errors, duration}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	families := append(append([]*family{}, e.families...), e.requestFamilies()...)
+	e.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeFamilies(w, families)
+}
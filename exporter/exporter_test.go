@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+var (
+	wien = client.Station{Name: "Wien Hbf", Number: 1290401}
+	graz = client.Station{Name: "Graz Hbf", Number: 8100173}
+
+	start = oebbtest.Start
+)
+
+func TestExporter(t *testing.T) {
+	api := oebbtest.NewServer()
+	defer api.Close()
+
+	rj := client.Category{DisplayName: "RJ", Number: "553"}
+	api.AddConnections(client.Connection{
+		ID:   "c0",
+		From: client.DepartureStation{Departure: oebbtest.At(0)},
+		Sections: []client.Section{{
+			From: client.DepartureStation{
+				Departure:                  oebbtest.At(0),
+				DepartureDelay:             oebbtest.At(5),
+				DeparturePlatform:          "7",
+				DeparturePlatformDeviation: "9",
+			},
+			To:       client.ArrivalStation{Arrival: oebbtest.At(155)},
+			Category: rj,
+		}},
+	})
+	api.AddDepartures(wien.Number, client.Departure{
+		Category:  client.Category{ShortName: "S", Number: "3"},
+		Direction: `Wiener "Neustadt"`,
+		Departure: oebbtest.At(10),
+		Cancelled: true,
+	})
+
+	e := New(client.NewSession(api.Client()))
+	e.now = func() time.Time { return start }
+	e.Routes = []Route{{Name: "work", From: wien, To: graz}}
+	e.Stations = []client.Station{wien}
+
+	e.Collect()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	want := []string{
+		"# TYPE oebb_departure_delay_seconds gauge",
+		`oebb_route_up{route="work"} 1`,
+		`oebb_departure_delay_seconds{route="work",category="RJ",number="553"} 300`,
+		`oebb_arrival_delay_seconds{route="work",category="RJ",number="553"} 0`,
+		`oebb_cancelled{route="work",category="RJ",number="553"} 0`,
+		`oebb_platform_changed{route="work",category="RJ",number="553"} 1`,
+		`oebb_board_up{station="Wien Hbf"} 1`,
+		`oebb_board_cancelled{station="Wien Hbf",category="S",number="3",direction="Wiener \"Neustadt\""} 1`,
+		`oebb_api_requests_total{operation="connections"} 1`,
+		`oebb_api_errors_total{operation="departures"} 0`,
+		`oebb_api_request_duration_seconds_count{operation="connections"} 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics do not contain %q:\n%s", line, body)
+		}
+	}
+}
+
+func TestExporterFailure(t *testing.T) {
+	api := oebbtest.NewServer()
+	defer api.Close()
+	api.Fail(oebbtest.EndpointTimetable, oebbtest.ServerError)
+
+	e := New(client.NewSession(api.Client()))
+	e.Routes = []Route{{Name: "work", From: wien, To: graz}}
+	e.Collect()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`oebb_route_up{route="work"} 0`,
+		`oebb_api_errors_total{operation="connections"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics do not contain %q:\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metricType is the type of a metric family in the Prometheus text format.
+type metricType string
+
+const (
+	gauge   metricType = "gauge"
+	counter metricType = "counter"
+	summary metricType = "summary"
+)
+
+// label is a label name and value.
+type label struct {
+	name, value string
+}
+
+type sample struct {
+	// suffix is appended to the name of the family, e.g. "_sum".
+	suffix string
+	labels []label
+	value  float64
+}
+
+// family is a metric family in the Prometheus text exposition format.
+type family struct {
+	name    string
+	help    string
+	typ     metricType
+	samples []sample
+}
+
+func (f *family) add(value float64, labels ...label) {
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+func (f *family) addSuffix(suffix string, value float64, labels ...label) {
+	f.samples = append(f.samples, sample{suffix: suffix, labels: labels, value: value})
+}
+
+// writeFamilies writes families in the Prometheus text exposition format,
+// version 0.0.4. Families without samples are omitted.
+func writeFamilies(w io.Writer, families []*family) error {
+	for _, f := range families {
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+
+		lines := make([]string, 0, len(f.samples))
+		for _, s := range f.samples {
+			lines = append(lines, f.name+s.suffix+formatLabels(s.labels)+" "+formatValue(s.value))
+		}
+		sort.Strings(lines)
+		for _, line := range lines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatLabels(labels []label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.name + `="` + escapeLabelValue(l.value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelReplacer.Replace(s)
+}
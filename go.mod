@@ -1,11 +1,11 @@
 module github.com/chrboe/oebb
 
-go 1.12
+go 1.15
 
 require (
 	github.com/adrg/xdg v0.0.0-20190319220657-88e5137d2444
 	github.com/aybabtme/rgbterm v0.0.0-20170906152045-cc83f3b3ce59
 	github.com/briandowns/spinner v0.0.0-20190319032542-ac46072a5a91
 	github.com/chrboe/oebb-cli v0.0.2-alpha
-	github.com/spf13/cobra v0.0.3
+	github.com/spf13/cobra v1.4.0
 )
@@ -0,0 +1,316 @@
+// Package monitor watches routes for delays, cancellations and platform
+// changes and notifies about them. It keeps track of what it already notified
+// about, so every change results in a single notification.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+)
+
+// DefaultResults is the default number of upcoming connections watched per
+// route.
+const DefaultResults = 3
+
+// DefaultThreshold is the default delay from which on delays are notified.
+const DefaultThreshold = 5 * time.Minute
+
+// delayStep is the increase of a delay which is notified again. Smaller
+// changes and decreasing delays are not notified.
+const delayStep = 5 * time.Minute
+
+// Route is a connection search which is watched.
+type Route struct {
+	Name     string
+	From, To client.Station
+	Options  client.ConnectionOptions
+}
+
+// EventKind is the kind of change an Event is about.
+type EventKind string
+
+// The kinds of events.
+const (
+	Delay          EventKind = "delay"
+	Cancellation   EventKind = "cancellation"
+	PlatformChange EventKind = "platform_change"
+)
+
+// Event is a change of a train which is part of a watched connection.
+type Event struct {
+	Kind  EventKind `json:"kind"`
+	Route string    `json:"route"`
+	// Train is the category and number of the train, e.g. "RJ 553".
+	Train string `json:"train"`
+	// Station is the station the train departs from.
+	Station string `json:"station"`
+	// Departure is the scheduled departure from Station.
+	Departure time.Time `json:"departure"`
+	// Delay is the expected departure delay. It is also set for other kinds
+	// of events.
+	Delay time.Duration `json:"-"`
+	// Platform is the platform the train departs from, if it changed.
+	Platform string `json:"platform,omitempty"`
+}
+
+// Title returns a short summary of the event.
+func (e Event) Title() string {
+	switch e.Kind {
+	case Cancellation:
+		return fmt.Sprintf("%s cancelled", e.Train)
+	case PlatformChange:
+		return fmt.Sprintf("%s: platform %s", e.Train, e.Platform)
+	default:
+		return fmt.Sprintf("%s delayed by %d min", e.Train, int(e.Delay.Minutes()))
+	}
+}
+
+// Message describes the event in a sentence.
+func (e Event) Message() string {
+	dep := e.Departure.Format("15:04")
+	switch e.Kind {
+	case Cancellation:
+		return fmt.Sprintf("%s from %s at %s (%s) is cancelled.", e.Train, e.Station, dep, e.Route)
+	case PlatformChange:
+		return fmt.Sprintf("%s from %s at %s (%s) departs from platform %s.", e.Train, e.Station, dep, e.Route, e.Platform)
+	default:
+		return fmt.Sprintf("%s from %s at %s (%s) is delayed by %d min.", e.Train, e.Station, dep, e.Route, int(e.Delay.Minutes()))
+	}
+}
+
+// key identifies the train and kind of change of the event, and detail the
+// change itself. An event is only notified if it changes the detail last
+// notified for its key.
+func (e Event) key() string {
+	return strings.Join([]string{e.Route, string(e.Kind), e.Train, e.Station, e.Departure.Format(time.RFC3339)}, "|")
+}
+
+func (e Event) detail() string {
+	switch e.Kind {
+	case Delay:
+		// the delay in minutes, rounded down to steps of delayStep
+		return fmt.Sprint(int((e.Delay / delayStep * delayStep).Minutes()))
+	case PlatformChange:
+		return e.Platform
+	default:
+		return "cancelled"
+	}
+}
+
+// changes reports whether the event differs from the detail last notified.
+// Delays only change if they grew by at least delayStep.
+func (e Event) changes(notified string) bool {
+	if e.Kind == Delay {
+		last, err := strconv.Atoi(notified)
+		next, _ := strconv.Atoi(e.detail())
+		return err != nil || next > last
+	}
+	return e.detail() != notified
+}
+
+// Monitor checks routes and notifies about changes. Create it using New.
+type Monitor struct {
+	// Session is used for all requests.
+	Session *client.Session
+
+	Routes []Route
+
+	// Results is the number of upcoming connections watched per route. If
+	// zero, DefaultResults is used.
+	Results int
+
+	// Threshold is the delay from which on delays are notified. If zero,
+	// DefaultThreshold is used.
+	Threshold time.Duration
+
+	// Notifiers are notified about every event.
+	Notifiers []Notifier
+
+	// State records the events already notified. If nil, every check
+	// notifies about all events.
+	State *State
+
+	// Logger receives diagnostic events, if set.
+	Logger client.Logger
+
+	// Record is called with the connections of every successful search, if
+	// set, e.g. to record delays.
+	Record func(connections []client.Connection)
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+// New returns a monitor using session, with the default settings.
+func New(session *client.Session) *Monitor {
+	return &Monitor{
+		Session: session,
+		now:     time.Now,
+	}
+}
+
+func (m *Monitor) log(msg string, keyvals ...interface{}) {
+	if m.Logger != nil {
+		m.Logger.Log(msg, keyvals...)
+	}
+}
+
+func (m *Monitor) results() int {
+	if m.Results > 0 {
+		return m.Results
+	}
+	return DefaultResults
+}
+
+func (m *Monitor) threshold() time.Duration {
+	if m.Threshold > 0 {
+		return m.Threshold
+	}
+	return DefaultThreshold
+}
+
+// trainName returns the category and number of a train.
+func trainName(c client.Category) string {
+	name := c.DisplayName
+	if name == "" {
+		name = c.ShortName
+	}
+	return strings.TrimSpace(name + " " + c.Number)
+}
+
+// detect returns the events of the sections of connections.
+func (m *Monitor) detect(r Route, connections []client.Connection) []Event {
+	var events []Event
+	for _, conn := range connections {
+		for _, s := range conn.Sections {
+			if !s.Kind().Vehicle() {
+				continue
+			}
+
+			dep, err := client.ParseTime(s.From.Departure)
+			if err != nil {
+				continue
+			}
+			delay, _ := s.From.Delay()
+
+			event := Event{
+				Route:     r.Name,
+				Train:     trainName(s.Category),
+				Station:   s.From.Name,
+				Departure: dep,
+				Delay:     delay,
+			}
+
+			if s.Cancelled {
+				event.Kind = Cancellation
+				events = append(events, event)
+				// delays and platforms of cancelled trains do not matter
+				continue
+			}
+
+			if delay >= m.threshold() {
+				event.Kind = Delay
+				events = append(events, event)
+			}
+
+			if s.From.PlatformChanged() {
+				event.Kind = PlatformChange
+				event.Platform = s.From.DeparturePlatformDeviation
+				events = append(events, event)
+			}
+		}
+	}
+
+	return events
+}
+
+// Check searches all routes once and notifies about new events. It returns the
+// events which were notified. Routes which cannot be searched are skipped, the
+// first error is returned after all routes were checked.
+func (m *Monitor) Check() ([]Event, error) {
+	now := m.now()
+
+	var (
+		notified []Event
+		firstErr error
+	)
+	for _, r := range m.Routes {
+		// cancelled trains are only returned when asked for
+		opts := r.Options
+		opts.IncludeCancelled = true
+
+		var connections []client.Connection
+		err := m.Session.Do(func(c *client.Client, a client.AuthInfo) error {
+			var err error
+			connections, err = c.GetConnectionsWithOptions(r.From, r.To, a, now, m.results(), opts)
+			return err
+		})
+		if err != nil {
+			m.log("failed to search route", "route", r.Name, "error", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to search route %s: %w", r.Name, err)
+			}
+			continue
+		}
+
+		if m.Record != nil {
+			m.Record(connections)
+		}
+
+		for _, event := range m.detect(r, connections) {
+			if m.State != nil && !m.State.update(event) {
+				continue
+			}
+
+			m.notify(event)
+			notified = append(notified, event)
+		}
+	}
+
+	if m.State != nil {
+		m.State.prune(now)
+	}
+
+	return notified, firstErr
+}
+
+func (m *Monitor) notify(event Event) {
+	m.log("notifying", "kind", event.Kind, "route", event.Route, "train", event.Train)
+	for _, n := range m.Notifiers {
+		if err := n.Notify(event); err != nil {
+			m.log("failed to notify", "notifier", fmt.Sprintf("%T", n), "error", err)
+		}
+	}
+}
+
+// Run checks every interval while schedule is active, until ctx is done. The
+// state is saved to statePath after every check, if both are set. Errors are
+// passed to the logger.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration, schedule Schedule, statePath string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if schedule.Active(m.now()) {
+			if _, err := m.Check(); err != nil {
+				m.log("check failed", "error", err)
+			}
+			if m.State != nil && statePath != "" {
+				if err := m.State.Save(statePath); err != nil {
+					m.log("failed to save state", "error", err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+)
+
+var (
+	wien = client.Station{Name: "Wien Hbf", Number: 1290401}
+	graz = client.Station{Name: "Graz Hbf", Number: 8100173}
+
+	start = oebbtest.Start
+)
+
+// section returns a section of the train RJ 553 departing at start, with the
+// given delay in minutes.
+func section(delay int) client.Section {
+	s := client.Section{
+		From:     client.DepartureStation{Name: wien.Name, Departure: oebbtest.At(0), DeparturePlatform: "7"},
+		To:       client.ArrivalStation{Name: graz.Name, Arrival: oebbtest.At(155)},
+		Category: client.Category{DisplayName: "RJ", Number: "553"},
+	}
+	if delay > 0 {
+		s.From.DepartureDelay = oebbtest.At(delay)
+	}
+	return s
+}
+
+// serve makes api return a single connection consisting of sections,
+// departing at start.
+func serve(api *oebbtest.Server, sections ...client.Section) {
+	api.HandleTimetable(func(req oebbtest.TimetableRequest) []client.Connection {
+		if dep, err := req.Departure(); err != nil || dep.After(start) {
+			return nil
+		}
+		return []client.Connection{{ID: "c0", From: client.DepartureStation{Departure: oebbtest.At(0)}, Sections: sections}}
+	})
+}
+
+func newMonitor(t *testing.T) (*Monitor, *oebbtest.Server, *[]Event) {
+	t.Helper()
+
+	api := oebbtest.NewServer()
+	t.Cleanup(api.Close)
+
+	var events []Event
+	m := New(client.NewSession(api.Client()))
+	m.now = func() time.Time { return start }
+	m.Routes = []Route{{Name: "work", From: wien, To: graz}}
+	m.State = NewState()
+	m.Notifiers = []Notifier{NotifierFunc(func(e Event) error {
+		events = append(events, e)
+		return nil
+	})}
+
+	return m, api, &events
+}
+
+func check(t *testing.T, m *Monitor) {
+	t.Helper()
+	if _, err := m.Check(); err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	m, api, events := newMonitor(t)
+
+	serve(api, section(3))
+	check(t, m)
+	if len(*events) != 0 {
+		t.Fatalf("got events %+v for delay below threshold, want none", *events)
+	}
+
+	serve(api, section(12))
+	check(t, m)
+	check(t, m)
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+
+	// small changes and decreasing delays are not notified
+	for _, delay := range []int{13, 14, 6, 14} {
+		serve(api, section(delay))
+		check(t, m)
+	}
+	if len(*events) != 1 {
+		t.Fatalf("got %d events after small changes of the delay, want 1", len(*events))
+	}
+
+	// a delay grown by five minutes is notified again
+	serve(api, section(15))
+	check(t, m)
+	if len(*events) != 2 {
+		t.Fatalf("got %d events after the delay grew, want 2", len(*events))
+	}
+	if e := (*events)[1]; e.Delay != 15*time.Minute {
+		t.Errorf("got event %+v, want a delay of 15 min", e)
+	}
+
+	e := (*events)[0]
+	if e.Kind != Delay || e.Delay != 12*time.Minute || e.Train != "RJ 553" || e.Route != "work" {
+		t.Errorf("got event %+v", e)
+	}
+	if want := "RJ 553 from Wien Hbf at 08:00 (work) is delayed by 12 min."; e.Message() != want {
+		t.Errorf("got message %q, want %q", e.Message(), want)
+	}
+}
+
+func TestNotifyOnce(t *testing.T) {
+	s := section(0)
+	s.From.DeparturePlatformDeviation = "9"
+	cancelled := section(10)
+	cancelled.Category.Number = "555"
+	cancelled.Cancelled = true
+	m, api, events := newMonitor(t)
+	serve(api, s, cancelled)
+
+	check(t, m)
+	check(t, m)
+
+	if len(*events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(*events), *events)
+	}
+	if (*events)[0].Kind != PlatformChange || (*events)[0].Platform != "9" {
+		t.Errorf("got event %+v, want platform change to 9", (*events)[0])
+	}
+	if (*events)[1].Kind != Cancellation || (*events)[1].Train != "RJ 555" {
+		t.Errorf("got event %+v, want cancellation of RJ 555", (*events)[1])
+	}
+
+	// the state survives a restart
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := m.State.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m2, api2, events2 := newMonitor(t)
+	serve(api2, s, cancelled)
+	m2.State = state
+	check(t, m2)
+	if len(*events2) != 0 {
+		t.Errorf("got events %+v after restart, want none", *events2)
+	}
+}
+
+func TestLoadStateNull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := ioutil.WriteFile(path, []byte("null"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+
+	m, api, events := newMonitor(t)
+	serve(api, section(12))
+	m.State = state
+	check(t, m)
+	if len(*events) != 1 {
+		t.Errorf("got %d events, want 1", len(*events))
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	e := Event{Kind: Delay, Route: "work", Train: "RJ 553", Station: wien.Name, Departure: start, Delay: 12 * time.Minute}
+	if err := (Webhook{URL: srv.URL}).Notify(e); err != nil {
+		t.Fatalf("Notify() failed: %v", err)
+	}
+
+	if got["kind"] != "delay" || got["delayMinutes"] != float64(12) || got["title"] != "RJ 553 delayed by 12 min" {
+		t.Errorf("got payload %v", got)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	monday := time.Date(2020, 5, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		day      int
+		time     string
+		active   bool
+	}{
+		{"", 0, "03:00", true},
+		{"mon-fri 06:00-09:00", 0, "07:30", true},
+		{"mon-fri 06:00-09:00", 0, "09:00", false},
+		{"mon-fri 06:00-09:00", 5, "07:30", false},
+		{"sat,sun", 6, "12:00", true},
+		{"fri-mon", 0, "12:00", true},
+		{"fri-mon", 2, "12:00", false},
+		{"fri 22:00-02:00", 4, "23:00", true},
+		{"fri 22:00-02:00", 5, "01:00", true},
+		{"fri 22:00-02:00", 4, "01:00", false},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.schedule)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) failed: %v", tt.schedule, err)
+		}
+
+		tod, _ := time.Parse("15:04", tt.time)
+		at := monday.AddDate(0, 0, tt.day).Add(time.Duration(tod.Hour())*time.Hour + time.Duration(tod.Minute())*time.Minute)
+		if got := s.Active(at); got != tt.active {
+			t.Errorf("schedule %q at %s: got active %v, want %v", tt.schedule, at.Format("Mon 15:04"), got, tt.active)
+		}
+	}
+
+	for _, invalid := range []string{"monday", "06:00", "mon-fri 06:00-09:00 10:00-11:00"} {
+		if _, err := ParseSchedule(invalid); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", invalid)
+		}
+	}
+}
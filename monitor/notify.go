@@ -0,0 +1,140 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+)
+
+// Notifier delivers notifications about events.
+type Notifier interface {
+	Notify(e Event) error
+}
+
+// NotifierFunc adapts an ordinary function to the Notifier interface.
+type NotifierFunc func(e Event) error
+
+// Notify calls f(e).
+func (f NotifierFunc) Notify(e Event) error {
+	return f(e)
+}
+
+// Writer writes the message of every event as a line to W.
+type Writer struct {
+	W io.Writer
+}
+
+// Notify implements Notifier.
+func (w Writer) Notify(e Event) error {
+	_, err := fmt.Fprintln(w.W, e.Message())
+	return err
+}
+
+// NotifySend shows desktop notifications using the notify-send command.
+type NotifySend struct {
+	// Urgency is passed to notify-send if set, e.g. "critical".
+	Urgency string
+}
+
+// Notify implements Notifier.
+func (n NotifySend) Notify(e Event) error {
+	args := []string{"--app-name=oebb-cli"}
+	if n.Urgency != "" {
+		args = append(args, "--urgency="+n.Urgency)
+	}
+	args = append(args, e.Title(), e.Message())
+
+	out, err := exec.Command("notify-send", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("notify-send failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// webhookPayload is the body POSTed by Webhook.
+type webhookPayload struct {
+	Event
+	DelayMinutes int    `json:"delayMinutes"`
+	Title        string `json:"title"`
+	Message      string `json:"message"`
+}
+
+func newWebhookPayload(e Event) webhookPayload {
+	return webhookPayload{
+		Event:        e,
+		DelayMinutes: int(e.Delay.Minutes()),
+		Title:        e.Title(),
+		Message:      e.Message(),
+	}
+}
+
+// Webhook POSTs every event as JSON to URL. The body contains the fields of
+// Event along with its delay in minutes, title and message.
+type Webhook struct {
+	URL string
+
+	// HTTPClient is used to send requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+}
+
+// Notify implements Notifier.
+func (w Webhook) Notify(e Event) error {
+	body, err := json.Marshal(newWebhookPayload(e))
+	if err != nil {
+		return err
+	}
+
+	httpClient := w.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned status %d", w.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// Exec runs a command for every event. The event is passed as JSON on stdin,
+// like the body of a Webhook, and in the environment variables OEBB_KIND,
+// OEBB_ROUTE, OEBB_TRAIN, OEBB_TITLE and OEBB_MESSAGE.
+type Exec struct {
+	Path string
+	Args []string
+}
+
+// Notify implements Notifier.
+func (x Exec) Notify(e Event) error {
+	body, err := json.Marshal(newWebhookPayload(e))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(x.Path, x.Args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"OEBB_KIND="+string(e.Kind),
+		"OEBB_ROUTE="+e.Route,
+		"OEBB_TRAIN="+e.Train,
+		"OEBB_TITLE="+e.Title(),
+		"OEBB_MESSAGE="+e.Message(),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", x.Path, err)
+	}
+	return nil
+}
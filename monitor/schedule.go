@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule restricts the times a monitor checks its routes. The zero value is
+// always active.
+type Schedule struct {
+	// Days are the weekdays the schedule is active on. If empty, it is
+	// active every day.
+	Days []time.Weekday
+
+	// Start and End are the times of day between which the schedule is
+	// active. If End is before Start, the time span extends past midnight.
+	// If both are zero, the schedule is active the whole day.
+	Start, End time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseSchedule parses a schedule consisting of weekdays and a time span,
+// either of which can be omitted, e.g. "mon-fri 06:00-09:00", "sat,sun" or
+// "07:00-08:30". An empty string is always active.
+func ParseSchedule(str string) (Schedule, error) {
+	var s Schedule
+	for _, field := range strings.Fields(strings.ToLower(str)) {
+		if strings.Contains(field, ":") {
+			if s.Start != 0 || s.End != 0 {
+				return Schedule{}, fmt.Errorf("invalid schedule %q: more than one time span", str)
+			}
+
+			parts := strings.Split(field, "-")
+			if len(parts) != 2 {
+				return Schedule{}, fmt.Errorf("invalid time span %q, expected e.g. 06:00-09:00", field)
+			}
+
+			var err error
+			if s.Start, err = parseTimeOfDay(parts[0]); err != nil {
+				return Schedule{}, err
+			}
+			if s.End, err = parseTimeOfDay(parts[1]); err != nil {
+				return Schedule{}, err
+			}
+			continue
+		}
+
+		days, err := parseWeekdays(field)
+		if err != nil {
+			return Schedule{}, err
+		}
+		s.Days = append(s.Days, days...)
+	}
+
+	return s, nil
+}
+
+func parseTimeOfDay(str string) (time.Duration, error) {
+	t, err := time.Parse("15:04", str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected e.g. 06:00", str)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseWeekdays parses a comma-separated list of weekdays and ranges of
+// weekdays, e.g. "mon-wed,fri".
+func parseWeekdays(str string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(str, ",") {
+		bounds := strings.Split(part, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid weekdays %q", part)
+		}
+
+		first, ok := weekdays[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q, expected e.g. mon", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdays[bounds[1]]; !ok {
+				return nil, fmt.Errorf("invalid weekday %q, expected e.g. fri", bounds[1])
+			}
+		}
+
+		for d := first; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// Active reports whether the schedule is active at t.
+func (s Schedule) Active(t time.Time) bool {
+	day := t.Weekday()
+	timeOfDay := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if s.Start != 0 || s.End != 0 {
+		if s.End < s.Start {
+			// past midnight, the time after midnight belongs to the
+			// previous day
+			if timeOfDay < s.End {
+				day = (day + 6) % 7
+			} else if timeOfDay < s.Start {
+				return false
+			}
+		} else if timeOfDay < s.Start || timeOfDay >= s.End {
+			return false
+		}
+	}
+
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
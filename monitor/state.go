@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// stateRetention is how long events are remembered after the scheduled
+// departure of their train.
+const stateRetention = 24 * time.Hour
+
+// State records which events were already notified.
+type State struct {
+	mu     sync.Mutex
+	events map[string]stateEntry
+}
+
+type stateEntry struct {
+	Detail    string    `json:"detail"`
+	Departure time.Time `json:"departure"`
+}
+
+// NewState returns an empty state.
+func NewState() *State {
+	return &State{events: map[string]stateEntry{}}
+}
+
+// LoadState reads a state saved using Save. If the file does not exist, an
+// empty state is returned.
+func LoadState(path string) (*State, error) {
+	s := NewState()
+
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bytes, &s.events); err != nil {
+		return nil, err
+	}
+	// a file containing null leaves no map to record events in
+	if s.events == nil {
+		s.events = map[string]stateEntry{}
+	}
+
+	return s, nil
+}
+
+// Save writes the state to path.
+func (s *State) Save(path string) error {
+	s.mu.Lock()
+	bytes, err := json.MarshalIndent(s.events, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, bytes, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// update records event and reports whether it is new or changed since it was
+// last recorded.
+func (s *State) update(event Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := event.key()
+	if entry, ok := s.events[key]; ok && !event.changes(entry.Detail) {
+		return false
+	}
+
+	s.events[key] = stateEntry{Detail: event.detail(), Departure: event.Departure}
+	return true
+}
+
+// prune forgets events of trains which departed long ago.
+func (s *State) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.events {
+		if now.Sub(entry.Departure) > stateRetention {
+			delete(s.events, key)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package server
+
+// openAPIDocument describes the REST API, served at /openapi.json.
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "oebb-cli REST API",
+    "description": "Stations, connections and departures from the ÖBB Tickets API.",
+    "version": "1.0.0"
+  },
+  "paths": {
+    "/stations": {
+      "get": {
+        "summary": "Look up stations by name",
+        "operationId": "getStations",
+        "parameters": [
+          {"name": "q", "in": "query", "required": true, "description": "Station name", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Matching stations, best match first",
+            "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Station"}}}}
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/connections": {
+      "get": {
+        "summary": "Search connections between two stations",
+        "operationId": "getConnections",
+        "parameters": [
+          {"name": "from", "in": "query", "required": true, "description": "Name of the departure station", "schema": {"type": "string"}},
+          {"name": "to", "in": "query", "required": true, "description": "Name of the arrival station", "schema": {"type": "string"}},
+          {"$ref": "#/components/parameters/Time"},
+          {"name": "results", "in": "query", "description": "Number of connections", "schema": {"type": "integer", "minimum": 1, "maximum": 50, "default": 5}},
+          {"name": "direct", "in": "query", "description": "Only return connections without changes", "schema": {"type": "boolean", "default": false}},
+          {"name": "wheelchair", "in": "query", "description": "Only return connections accessible by wheelchair", "schema": {"type": "boolean", "default": false}},
+          {"name": "assistanceDog", "in": "query", "description": "The passenger travels with an assistance dog", "schema": {"type": "boolean", "default": false}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Connections, earliest departure first",
+            "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Connection"}}}}
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    },
+    "/departures/{station}": {
+      "get": {
+        "summary": "List the departures from a station",
+        "operationId": "getDepartures",
+        "parameters": [
+          {"name": "station", "in": "path", "required": true, "description": "Station name", "schema": {"type": "string"}},
+          {"$ref": "#/components/parameters/Time"},
+          {"name": "results", "in": "query", "description": "Number of departures", "schema": {"type": "integer", "minimum": 1, "maximum": 50, "default": 10}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Departures, earliest first",
+            "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Departure"}}}}
+          },
+          "400": {"$ref": "#/components/responses/BadRequest"},
+          "404": {"$ref": "#/components/responses/NotFound"},
+          "502": {"$ref": "#/components/responses/BadGateway"}
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "Time": {
+        "name": "time",
+        "in": "query",
+        "description": "Departure time in RFC 3339 format or as 15:04 on the current day. Defaults to now.",
+        "schema": {"type": "string"}
+      }
+    },
+    "responses": {
+      "BadRequest": {"description": "Invalid parameters", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+      "NotFound": {"description": "Station not found", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+      "BadGateway": {"description": "The ÖBB API failed", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+    },
+    "schemas": {
+      "Error": {
+        "type": "object",
+        "properties": {
+          "error": {
+            "type": "object",
+            "properties": {
+              "status": {"type": "integer"},
+              "message": {"type": "string"}
+            }
+          }
+        }
+      },
+      "Station": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "number": {"type": "integer"},
+          "latitude": {"type": "integer", "description": "Latitude in millionths of a degree"},
+          "longitude": {"type": "integer", "description": "Longitude in millionths of a degree"}
+        }
+      },
+      "TranslatedString": {
+        "type": "object",
+        "properties": {
+          "de": {"type": "string"},
+          "en": {"type": "string"},
+          "it": {"type": "string"}
+        }
+      },
+      "Category": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "number": {"type": "string"},
+          "shortName": {"type": "string"},
+          "displayName": {"type": "string"},
+          "longName": {"$ref": "#/components/schemas/TranslatedString"},
+          "train": {"type": "boolean"}
+        }
+      },
+      "DepartureStation": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "esn": {"type": "integer"},
+          "departure": {"type": "string", "example": "2020-05-04T08:00:00.000"},
+          "departureDelay": {"type": "string"},
+          "departurePlatform": {"type": "string"},
+          "departurePlatformDeviation": {"type": "string"}
+        }
+      },
+      "ArrivalStation": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "esn": {"type": "integer"},
+          "arrival": {"type": "string", "example": "2020-05-04T10:35:00.000"},
+          "arrivalDelay": {"type": "string"},
+          "arrivalPlatform": {"type": "string"},
+          "arrivalPlatformDeviation": {"type": "string"}
+        }
+      },
+      "Section": {
+        "type": "object",
+        "properties": {
+          "from": {"$ref": "#/components/schemas/DepartureStation"},
+          "to": {"$ref": "#/components/schemas/ArrivalStation"},
+          "duration": {"type": "integer", "description": "Duration in milliseconds"},
+          "category": {"$ref": "#/components/schemas/Category"},
+          "type": {"type": "string", "description": "Section type, e.g. journey, walk, transfer, bus or taxi"},
+          "hasRealtime": {"type": "boolean"},
+          "distance": {"type": "integer", "description": "Length of a walk in meters"},
+          "cancelled": {"type": "boolean"},
+          "partiallyCancelled": {"type": "boolean", "description": "The train skips some stops or ends early"},
+          "cancellationReason": {"type": "string"},
+          "notes": {"type": "array", "items": {"$ref": "#/components/schemas/Note"}},
+          "occupancy": {
+            "type": "object",
+            "description": "Expected load per class, from 0 (unknown) and 1 (low) to 4 (full)",
+            "properties": {
+              "first": {"type": "integer", "minimum": 0, "maximum": 4},
+              "second": {"type": "integer", "minimum": 0, "maximum": 4}
+            }
+          },
+          "accessibility": {
+            "type": "object",
+            "properties": {
+              "stepFree": {"type": "boolean", "description": "The train can be boarded without steps"},
+              "wheelchairSpaces": {"type": "integer"},
+              "assistanceRequired": {"type": "boolean", "description": "Boarding requires assistance booked in advance"}
+            }
+          }
+        }
+      },
+      "Connection": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "from": {"$ref": "#/components/schemas/DepartureStation"},
+          "to": {"$ref": "#/components/schemas/ArrivalStation"},
+          "sections": {"type": "array", "items": {"$ref": "#/components/schemas/Section"}},
+          "switches": {"type": "integer", "description": "Number of changes"},
+          "duration": {"type": "integer", "description": "Duration in milliseconds"},
+          "notes": {"type": "array", "items": {"$ref": "#/components/schemas/Note"}}
+        }
+      },
+      "Note": {
+        "type": "object",
+        "description": "A disruption notice or remark",
+        "properties": {
+          "type": {"type": "string", "enum": ["him", "remark"]},
+          "code": {"type": "string"},
+          "severity": {"type": "string", "enum": ["info", "warning", "critical"]},
+          "title": {"$ref": "#/components/schemas/TranslatedString"},
+          "text": {"$ref": "#/components/schemas/TranslatedString"}
+        }
+      },
+      "Departure": {
+        "type": "object",
+        "properties": {
+          "category": {"$ref": "#/components/schemas/Category"},
+          "direction": {"type": "string", "description": "Final destination"},
+          "departure": {"type": "string", "example": "2020-05-04T08:00:00.000"},
+          "departureDelay": {"type": "string"},
+          "departurePlatform": {"type": "string"},
+          "departurePlatformDeviation": {"type": "string"},
+          "hasRealtime": {"type": "boolean"},
+          "cancelled": {"type": "boolean"}
+        }
+      }
+    }
+  }
+}
+`
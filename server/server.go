@@ -0,0 +1,359 @@
+// Package server provides a JSON REST API for the ÖBB Tickets API, so services
+// which are not written in Go can query stations, connections and departures
+// without handling sessions themselves.
+//
+// The endpoints are:
+//
+//	GET /stations?q=<name>
+//	GET /connections?from=<name>&to=<name>[&time=<time>][&results=<n>][&direct=true][&wheelchair=true][&assistanceDog=true]
+//	GET /departures/<station>[?time=<time>][&results=<n>]
+//	GET /openapi.json
+//
+// Times are given in RFC 3339 format or as "15:04" on the current day and
+// default to now. Errors are returned as {"error": {"status": 404, "message":
+// "..."}}.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+)
+
+// Limits of the number of results which can be requested.
+const (
+	defaultConnections = 5
+	defaultDepartures  = 10
+	maxResults         = 50
+)
+
+// Server serves the REST API. Create it using New.
+type Server struct {
+	// Session is shared by all requests. Caching is configured on the
+	// client of the session.
+	Session *client.Session
+
+	// AllowedOrigins lists the origins which may send cross-origin
+	// requests. "*" allows all origins. If empty, no CORS headers are sent.
+	AllowedOrigins []string
+
+	// Logger receives an event for every request served, if set.
+	Logger client.Logger
+
+	mux *http.ServeMux
+}
+
+// New returns a server answering requests using session.
+func New(session *client.Session) *Server {
+	s := &Server{Session: session}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/stations", s.handleStations)
+	s.mux.HandleFunc("/connections", s.handleConnections)
+	s.mux.HandleFunc("/departures/", s.handleDepartures)
+	s.mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found: %s", r.URL.Path)
+	})
+
+	return s
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.serve(rec, r)
+
+	if s.Logger != nil {
+		s.Logger.Log("served request", "method", r.Method, "path", r.URL.Path, "status", rec.status, "latency", time.Since(start))
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	s.setCORSHeaders(w, r, preflight)
+	if preflight {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// setCORSHeaders allows the origin of r to read the response, if it is one of
+// the allowed origins.
+func (s *Server) setCORSHeaders(w http.ResponseWriter, r *http.Request, preflight bool) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+
+	allowed := ""
+	for _, o := range s.AllowedOrigins {
+		if o == "*" {
+			allowed = "*"
+			break
+		}
+		if o == origin {
+			allowed = origin
+			w.Header().Add("Vary", "Origin")
+			break
+		}
+	}
+	if allowed == "" {
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", allowed)
+	if preflight {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		w.Header().Set("Access-Control-Max-Age", "86400")
+	}
+}
+
+// errorResponse is the body of error responses.
+type errorResponse struct {
+	Error struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	var resp errorResponse
+	resp.Error.Status = status
+	resp.Error.Message = fmt.Sprintf(format, args...)
+	writeJSON(w, status, resp)
+}
+
+// writeAPIError reports an error returned by the ÖBB API.
+func writeAPIError(w http.ResponseWriter, err error) {
+	var statusErr *client.StatusError
+	if errors.As(err, &statusErr) {
+		writeError(w, http.StatusBadGateway, "ÖBB API returned status %d", statusErr.StatusCode)
+		return
+	}
+	writeError(w, http.StatusBadGateway, "ÖBB API request failed: %s", err)
+}
+
+// parseTime parses a time given either in RFC 3339 format or as "15:04" on the
+// current day. An empty string means now.
+func parseTime(str string) (time.Time, error) {
+	if str == "" {
+		return time.Now(), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("15:04", str, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or 15:04", str)
+	}
+
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local), nil
+}
+
+// parseResults parses the number of results requested, which defaults to def.
+func parseResults(str string, def int) (int, error) {
+	if str == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(str)
+	if err != nil || n < 1 || n > maxResults {
+		return 0, fmt.Errorf("results must be a number between 1 and %d", maxResults)
+	}
+	return n, nil
+}
+
+func (s *Server) getStations(name string) ([]client.Station, error) {
+	var stations []client.Station
+	err := s.Session.Do(func(c *client.Client, a client.AuthInfo) error {
+		var err error
+		stations, err = c.GetStations(name, a)
+		return err
+	})
+	return stations, err
+}
+
+// lookupStation returns the best match for name. It writes an error response
+// and returns false if there is none.
+func (s *Server) lookupStation(w http.ResponseWriter, name string) (client.Station, bool) {
+	stations, err := s.getStations(name)
+	if err != nil {
+		writeAPIError(w, err)
+		return client.Station{}, false
+	}
+
+	if len(stations) < 1 {
+		writeError(w, http.StatusNotFound, "no station found for %q", name)
+		return client.Station{}, false
+	}
+
+	return stations[0], true
+}
+
+func (s *Server) handleStations(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("q")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing parameter q")
+		return
+	}
+
+	stations, err := s.getStations(name)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if stations == nil {
+		stations = []client.Station{}
+	}
+	writeJSON(w, http.StatusOK, stations)
+}
+
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fromName, toName := query.Get("from"), query.Get("to")
+	if fromName == "" || toName == "" {
+		writeError(w, http.StatusBadRequest, "missing parameter from or to")
+		return
+	}
+
+	depTime, err := parseTime(query.Get("time"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	numResults, err := parseResults(query.Get("results"), defaultConnections)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	var opts client.ConnectionOptions
+	flags := []struct {
+		name  string
+		value *bool
+	}{
+		{"direct", &opts.Direct},
+		{"wheelchair", &opts.Accessibility.Wheelchair},
+		{"assistanceDog", &opts.Accessibility.AssistanceDog},
+	}
+	for _, f := range flags {
+		if str := query.Get(f.name); str != "" {
+			*f.value, err = strconv.ParseBool(str)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid value %q for %s", str, f.name)
+				return
+			}
+		}
+	}
+
+	from, ok := s.lookupStation(w, fromName)
+	if !ok {
+		return
+	}
+	to, ok := s.lookupStation(w, toName)
+	if !ok {
+		return
+	}
+
+	var connections []client.Connection
+	err = s.Session.Do(func(c *client.Client, a client.AuthInfo) error {
+		connections, err = c.GetConnectionsWithOptions(from, to, a, depTime, numResults, opts)
+		return err
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if connections == nil {
+		connections = []client.Connection{}
+	}
+	writeJSON(w, http.StatusOK, connections)
+}
+
+func (s *Server) handleDepartures(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/departures/")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing station")
+		return
+	}
+
+	query := r.URL.Query()
+	depTime, err := parseTime(query.Get("time"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	numResults, err := parseResults(query.Get("results"), defaultDepartures)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	station, ok := s.lookupStation(w, name)
+	if !ok {
+		return
+	}
+
+	var departures []client.Departure
+	err = s.Session.Do(func(c *client.Client, a client.AuthInfo) error {
+		departures, err = c.GetDepartures(station, a, depTime, numResults)
+		return err
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if departures == nil {
+		departures = []client.Departure{}
+	}
+	writeJSON(w, http.StatusOK, departures)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, openAPIDocument)
+}
@@ -0,0 +1,199 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrboe/oebb/client"
+	"github.com/chrboe/oebb/client/oebbtest"
+	"github.com/chrboe/oebb/server"
+)
+
+var (
+	wien = client.Station{Name: "Wien Hbf", Number: 1290401}
+	graz = client.Station{Name: "Graz Hbf", Number: 8100173}
+)
+
+func newServer(t *testing.T) (*oebbtest.Server, *server.Server) {
+	t.Helper()
+
+	api := oebbtest.NewServer()
+	t.Cleanup(api.Close)
+	api.AddStations("wien", wien)
+	api.AddStations("graz", graz)
+
+	return api, server.New(client.NewSession(api.Client()))
+}
+
+func get(t *testing.T, s http.Handler, url string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: invalid JSON %q: %v", url, rec.Body.String(), err)
+		}
+	}
+
+	return rec
+}
+
+func TestStations(t *testing.T) {
+	_, s := newServer(t)
+
+	var stations []client.Station
+	rec := get(t, s, "/stations?q=Wien", &stations)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+	if len(stations) != 1 || stations[0].Number != wien.Number {
+		t.Errorf("got stations %+v, want %+v", stations, wien)
+	}
+}
+
+func TestConnections(t *testing.T) {
+	api, s := newServer(t)
+	dep := time.Date(2020, 5, 4, 8, 0, 0, 0, time.UTC)
+	api.AddConnections(client.Connection{
+		ID:   "c0",
+		From: client.DepartureStation{Name: wien.Name, Departure: client.FormatTime(dep)},
+		To:   client.ArrivalStation{Name: graz.Name, Arrival: client.FormatTime(dep.Add(2 * time.Hour))},
+	})
+
+	var connections []client.Connection
+	rec := get(t, s, "/connections?from=Wien&to=Graz&time=2020-05-04T07:30:00Z&results=2&wheelchair=true", &connections)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+	if len(connections) != 1 || connections[0].ID != "c0" {
+		t.Errorf("got connections %+v, want c0", connections)
+	}
+
+	trs, err := api.TimetableRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trs[0].From.Number != wien.Number || trs[0].To.Number != graz.Number || trs[0].Count != 2 || !trs[0].Filter["wheelchair"] {
+		t.Errorf("got timetable request %+v", trs[0])
+	}
+}
+
+func TestDepartures(t *testing.T) {
+	api, s := newServer(t)
+	dep := time.Date(2020, 5, 4, 8, 0, 0, 0, time.UTC)
+	api.AddDepartures(wien.Number,
+		client.Departure{Direction: "Graz Hbf", Departure: client.FormatTime(dep)},
+		client.Departure{Direction: "Salzburg Hbf", Departure: client.FormatTime(dep.Add(time.Hour))},
+	)
+
+	var departures []client.Departure
+	rec := get(t, s, "/departures/Wien?time=2020-05-04T08:30:00Z", &departures)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+	if len(departures) != 1 || departures[0].Direction != "Salzburg Hbf" {
+		t.Errorf("got departures %+v, want the one to Salzburg Hbf", departures)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"/stations", http.StatusBadRequest},
+		{"/connections?from=Wien", http.StatusBadRequest},
+		{"/connections?from=Wien&to=Graz&time=noon", http.StatusBadRequest},
+		{"/connections?from=Wien&to=Graz&results=100", http.StatusBadRequest},
+		{"/connections?from=Wien&to=Graz&wheelchair=maybe", http.StatusBadRequest},
+		{"/connections?from=Wien&to=Linz", http.StatusNotFound},
+		{"/departures/Linz", http.StatusNotFound},
+		{"/unknown", http.StatusNotFound},
+	}
+
+	_, s := newServer(t)
+	for _, tt := range tests {
+		var resp struct {
+			Error struct {
+				Status  int    `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		rec := get(t, s, tt.url, &resp)
+		if rec.Code != tt.status || resp.Error.Status != tt.status || resp.Error.Message == "" {
+			t.Errorf("GET %s: got status %d and error %+v, want status %d", tt.url, rec.Code, resp.Error, tt.status)
+		}
+	}
+}
+
+func TestAPIFailure(t *testing.T) {
+	api, s := newServer(t)
+	api.Fail(oebbtest.EndpointStations, oebbtest.ServerError)
+
+	if rec := get(t, s, "/stations?q=Wien", nil); rec.Code != http.StatusBadGateway {
+		t.Errorf("got status %d, want 502", rec.Code)
+	}
+}
+
+func TestSharedSession(t *testing.T) {
+	api, s := newServer(t)
+
+	get(t, s, "/stations?q=Wien", nil)
+	api.ExpireSession()
+	if rec := get(t, s, "/stations?q=Graz", nil); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d after the session expired, want 200", rec.Code)
+	}
+	get(t, s, "/stations?q=Wien", nil)
+
+	if n := len(api.Requests(oebbtest.EndpointAuth)); n != 2 {
+		t.Errorf("authenticated %d times, want 2", n)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	_, s := newServer(t)
+	s.AllowedOrigins = []string{"https://example.com"}
+
+	req := httptest.NewRequest("OPTIONS", "/stations", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("got status %d for preflight request, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("got Access-Control-Allow-Origin %q, want https://example.com", got)
+	}
+
+	req = httptest.NewRequest("GET", "/stations?q=Wien", nil)
+	req.Header.Set("Origin", "https://other.example.com")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("got Access-Control-Allow-Origin %q for other origin, want none", got)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	_, s := newServer(t)
+
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	get(t, s, "/openapi.json", &doc)
+
+	for _, path := range []string{"/stations", "/connections", "/departures/{station}"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("path %s not documented", path)
+		}
+	}
+}